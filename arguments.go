@@ -2,7 +2,6 @@ package smartapi
 
 import (
 	"context"
-	"encoding/json"
 	"encoding/xml"
 	"errors"
 	"fmt"
@@ -24,6 +23,10 @@ const (
 	flagReadsRequestBody
 	flagWritesResponse
 	flagError
+	flagEndpointOption
+	flagReadsMultipartForm
+	flagEventStream
+	flagWebSocket
 )
 
 func (e endpointOptions) has(o endpointOptions) bool {
@@ -52,6 +55,7 @@ func (e errorEndpointParam) options() endpointOptions {
 
 type headerArgument struct {
 	name string
+	opts paramOptions
 }
 
 func (a headerArgument) options() endpointOptions {
@@ -59,23 +63,38 @@ func (a headerArgument) options() endpointOptions {
 }
 
 func (a headerArgument) getValue(w http.ResponseWriter, r *http.Request) (reflect.Value, error) {
-	return reflect.ValueOf(r.Header.Get(a.name)), nil
+	value, _ := a.value(r)
+	return reflect.ValueOf(value), nil
 }
 
 func (a headerArgument) checkArg(arg reflect.Type) error {
-	if arg.Kind() != reflect.String {
-		return errors.New("expected a string type")
-	}
-	return nil
+	return checkListArg(arg)
+}
+
+func (a headerArgument) value(r *http.Request) (string, bool) {
+	return paramValue(r.Header[http.CanonicalHeaderKey(a.name)], a.opts)
+}
+
+func (a headerArgument) lookup(r *http.Request) []string {
+	return listValues(r.Header, http.CanonicalHeaderKey(a.name), a.opts)
 }
 
-// Header reads a header from the request and passes it as string to a function
-func Header(name string) EndpointParam {
-	return headerArgument{name: name}
+func (headerArgument) isRequired() bool {
+	return false
+}
+
+func (a headerArgument) describe() string {
+	return "header " + a.name
+}
+
+// Header reads a header from the request and passes it as string or converted to the argument's type
+func Header(name string, options ...ParamOption) EndpointParam {
+	return headerArgument{name: name, opts: newParamOptions(options)}
 }
 
 type requiredHeaderArgument struct {
 	name string
+	opts paramOptions
 }
 
 func (a requiredHeaderArgument) options() endpointOptions {
@@ -83,7 +102,7 @@ func (a requiredHeaderArgument) options() endpointOptions {
 }
 
 func (a requiredHeaderArgument) getValue(w http.ResponseWriter, r *http.Request) (reflect.Value, error) {
-	value := r.Header.Get(a.name)
+	value, _ := a.value(r)
 	if len(value) == 0 {
 		msg := fmt.Sprintf("missing required header %s", a.name)
 		return reflect.Value{}, Error(http.StatusBadRequest, msg, msg)
@@ -92,15 +111,28 @@ func (a requiredHeaderArgument) getValue(w http.ResponseWriter, r *http.Request)
 }
 
 func (a requiredHeaderArgument) checkArg(arg reflect.Type) error {
-	if arg.Kind() != reflect.String {
-		return errors.New("expected a string type")
-	}
-	return nil
+	return checkListArg(arg)
 }
 
-// RequiredHeader reads a header from the request and passes it as string to a function
-func RequiredHeader(name string) EndpointParam {
-	return requiredHeaderArgument{name: name}
+func (a requiredHeaderArgument) value(r *http.Request) (string, bool) {
+	return paramValue(r.Header[http.CanonicalHeaderKey(a.name)], a.opts)
+}
+
+func (a requiredHeaderArgument) lookup(r *http.Request) []string {
+	return listValues(r.Header, http.CanonicalHeaderKey(a.name), a.opts)
+}
+
+func (requiredHeaderArgument) isRequired() bool {
+	return true
+}
+
+func (a requiredHeaderArgument) describe() string {
+	return "header " + a.name
+}
+
+// RequiredHeader reads a header from the request and passes it as string or converted to the argument's type
+func RequiredHeader(name string, options ...ParamOption) EndpointParam {
+	return requiredHeaderArgument{name: name, opts: newParamOptions(options)}
 }
 
 type jsonBodyArgument struct {
@@ -121,15 +153,23 @@ func (a jsonBodyArgument) checkArg(arg reflect.Type) error {
 func (a jsonBodyArgument) getValue(w http.ResponseWriter, r *http.Request) (reflect.Value, error) {
 	value := reflect.New(a.typ)
 	obj := value.Interface()
-	if err := json.NewDecoder(r.Body).Decode(obj); err != nil {
-		return reflect.Value{}, WrapError(http.StatusBadRequest, err, "cannot unmarshal request")
+	if err := decodeJSON(r, obj); err != nil {
+		return reflect.Value{}, bodyError(r, err, "cannot unmarshal request")
+	}
+	if err := validate(value); err != nil {
+		return reflect.Value{}, err
 	}
 	return value, nil
 }
 
-// JSONBody reads request's body and unmarshals it into a pointer to a json structure
+// JSONBody reads request's body and unmarshals it into a pointer to a json structure.
+// The structure is validated according to its validate tags.
 func JSONBody(v interface{}) EndpointParam {
-	return jsonBodyArgument{typ: reflect.TypeOf(v)}
+	typ := reflect.TypeOf(v)
+	if err := checkValidation(typ); err != nil {
+		return errorEndpointParam{err: err}
+	}
+	return jsonBodyArgument{typ: typ}
 }
 
 type jsonBodyDirectArgument struct {
@@ -150,15 +190,23 @@ func (a jsonBodyDirectArgument) checkArg(arg reflect.Type) error {
 func (a jsonBodyDirectArgument) getValue(w http.ResponseWriter, r *http.Request) (reflect.Value, error) {
 	value := reflect.New(a.typ)
 	obj := value.Interface()
-	if err := json.NewDecoder(r.Body).Decode(obj); err != nil {
-		return reflect.Value{}, WrapError(http.StatusBadRequest, err, "cannot unmarshal request")
+	if err := decodeJSON(r, obj); err != nil {
+		return reflect.Value{}, bodyError(r, err, "cannot unmarshal request")
+	}
+	if err := validate(value); err != nil {
+		return reflect.Value{}, err
 	}
 	return value.Elem(), nil
 }
 
-// JSONBodyDirect reads request's body and unmarshals it into a json structure
+// JSONBodyDirect reads request's body and unmarshals it into a json structure.
+// The structure is validated according to its validate tags.
 func JSONBodyDirect(v interface{}) EndpointParam {
-	return jsonBodyDirectArgument{typ: reflect.TypeOf(v)}
+	typ := reflect.TypeOf(v)
+	if err := checkValidation(typ); err != nil {
+		return errorEndpointParam{err: err}
+	}
+	return jsonBodyDirectArgument{typ: typ}
 }
 
 type xmlBodyArgument struct {
@@ -180,14 +228,22 @@ func (a xmlBodyArgument) getValue(w http.ResponseWriter, r *http.Request) (refle
 	value := reflect.New(a.typ)
 	obj := value.Interface()
 	if err := xml.NewDecoder(r.Body).Decode(obj); err != nil {
-		return reflect.Value{}, WrapError(http.StatusBadRequest, err, "cannot unmarshal request")
+		return reflect.Value{}, bodyError(r, err, "cannot unmarshal request")
+	}
+	if err := validate(value); err != nil {
+		return reflect.Value{}, err
 	}
 	return value, nil
 }
 
-// XMLBody reads request's body and unmarshals it into a pointer to an xml structure
+// XMLBody reads request's body and unmarshals it into a pointer to an xml structure.
+// The structure is validated according to its validate tags.
 func XMLBody(v interface{}) EndpointParam {
-	return xmlBodyArgument{typ: reflect.TypeOf(v)}
+	typ := reflect.TypeOf(v)
+	if err := checkValidation(typ); err != nil {
+		return errorEndpointParam{err: err}
+	}
+	return xmlBodyArgument{typ: typ}
 }
 
 type stringBodyArgument struct{}
@@ -206,7 +262,7 @@ func (s stringBodyArgument) checkArg(arg reflect.Type) error {
 func (s stringBodyArgument) getValue(w http.ResponseWriter, r *http.Request) (reflect.Value, error) {
 	result, err := ioutil.ReadAll(r.Body)
 	if err != nil {
-		return reflect.Value{}, WrapError(http.StatusBadRequest, err, "cannot read request")
+		return reflect.Value{}, bodyError(r, err, "cannot read request")
 	}
 	return reflect.ValueOf(string(result)), nil
 }
@@ -234,7 +290,7 @@ func (s byteSliceBodyArgument) checkArg(arg reflect.Type) error {
 func (s byteSliceBodyArgument) getValue(w http.ResponseWriter, r *http.Request) (reflect.Value, error) {
 	result, err := ioutil.ReadAll(r.Body)
 	if err != nil {
-		return reflect.Value{}, WrapError(http.StatusBadRequest, err, "cannot read request")
+		return reflect.Value{}, bodyError(r, err, "cannot read request")
 	}
 	return reflect.ValueOf(result), nil
 }
@@ -277,17 +333,27 @@ func (urlParamArgument) options() endpointOptions {
 }
 
 func (u urlParamArgument) checkArg(arg reflect.Type) error {
-	if arg.Kind() != reflect.String {
-		return errors.New("expected a string type")
-	}
-	return nil
+	return checkStringArg(arg)
+}
+
+func (u urlParamArgument) describe() string {
+	return "url param " + u.name
+}
+
+func (u urlParamArgument) value(r *http.Request) (string, bool) {
+	value := chi.URLParam(r, u.name)
+	return value, len(value) != 0
+}
+
+func (urlParamArgument) isRequired() bool {
+	return true
 }
 
 func (u urlParamArgument) getValue(w http.ResponseWriter, r *http.Request) (reflect.Value, error) {
 	return reflect.ValueOf(chi.URLParam(r, u.name)), nil
 }
 
-// URLParam reads a url param and passes it as a string
+// URLParam reads a url param and passes it as a string or converted to the argument's type
 func URLParam(name string) EndpointParam {
 	return urlParamArgument{name: name}
 }
@@ -332,6 +398,7 @@ func ResponseStatus(status int) EndpointParam {
 
 type queryParamArgument struct {
 	name string
+	opts paramOptions
 }
 
 func (queryParamArgument) options() endpointOptions {
@@ -339,23 +406,38 @@ func (queryParamArgument) options() endpointOptions {
 }
 
 func (q queryParamArgument) checkArg(arg reflect.Type) error {
-	if arg.Kind() != reflect.String {
-		return errors.New("expected a string type")
-	}
-	return nil
+	return checkListArg(arg)
+}
+
+func (q queryParamArgument) value(r *http.Request) (string, bool) {
+	return paramValue(r.Form[q.name], q.opts)
+}
+
+func (q queryParamArgument) lookup(r *http.Request) []string {
+	return listValues(r.Form, q.name, q.opts)
+}
+
+func (queryParamArgument) isRequired() bool {
+	return false
+}
+
+func (q queryParamArgument) describe() string {
+	return "query param " + q.name
 }
 
 func (q queryParamArgument) getValue(w http.ResponseWriter, r *http.Request) (reflect.Value, error) {
-	return reflect.ValueOf(r.Form.Get(q.name)), nil
+	value, _ := q.value(r)
+	return reflect.ValueOf(value), nil
 }
 
-// QueryParam reads a query param and passes it as a string
-func QueryParam(name string) EndpointParam {
-	return queryParamArgument{name: name}
+// QueryParam reads a query param and passes it as a string or converted to the argument's type
+func QueryParam(name string, options ...ParamOption) EndpointParam {
+	return queryParamArgument{name: name, opts: newParamOptions(options)}
 }
 
 type requiredQueryParamArgument struct {
 	name string
+	opts paramOptions
 }
 
 func (requiredQueryParamArgument) options() endpointOptions {
@@ -363,14 +445,27 @@ func (requiredQueryParamArgument) options() endpointOptions {
 }
 
 func (q requiredQueryParamArgument) checkArg(arg reflect.Type) error {
-	if arg.Kind() != reflect.String {
-		return errors.New("expected a string type")
-	}
-	return nil
+	return checkListArg(arg)
+}
+
+func (q requiredQueryParamArgument) value(r *http.Request) (string, bool) {
+	return paramValue(r.Form[q.name], q.opts)
+}
+
+func (q requiredQueryParamArgument) lookup(r *http.Request) []string {
+	return listValues(r.Form, q.name, q.opts)
+}
+
+func (requiredQueryParamArgument) isRequired() bool {
+	return true
+}
+
+func (q requiredQueryParamArgument) describe() string {
+	return "query param " + q.name
 }
 
 func (q requiredQueryParamArgument) getValue(w http.ResponseWriter, r *http.Request) (reflect.Value, error) {
-	value := r.Form.Get(q.name)
+	value, _ := q.value(r)
 	if len(value) == 0 {
 		m := fmt.Sprintf("missing required query param %s", q.name)
 		return reflect.Value{}, Error(http.StatusBadRequest, m, m)
@@ -378,13 +473,14 @@ func (q requiredQueryParamArgument) getValue(w http.ResponseWriter, r *http.Requ
 	return reflect.ValueOf(value), nil
 }
 
-// RequiredQueryParam reads a query param and passes it as a string. Returns 400 BAD REQUEST when empty
-func RequiredQueryParam(name string) EndpointParam {
-	return requiredQueryParamArgument{name: name}
+// RequiredQueryParam reads a query param and passes it as a string or converted to the argument's type. Returns 400 BAD REQUEST when empty
+func RequiredQueryParam(name string, options ...ParamOption) EndpointParam {
+	return requiredQueryParamArgument{name: name, opts: newParamOptions(options)}
 }
 
 type requiredPostQueryParamArgument struct {
 	name string
+	opts paramOptions
 }
 
 func (requiredPostQueryParamArgument) options() endpointOptions {
@@ -392,14 +488,27 @@ func (requiredPostQueryParamArgument) options() endpointOptions {
 }
 
 func (q requiredPostQueryParamArgument) checkArg(arg reflect.Type) error {
-	if arg.Kind() != reflect.String {
-		return errors.New("expected a string type")
-	}
-	return nil
+	return checkListArg(arg)
+}
+
+func (q requiredPostQueryParamArgument) value(r *http.Request) (string, bool) {
+	return paramValue(r.PostForm[q.name], q.opts)
+}
+
+func (q requiredPostQueryParamArgument) lookup(r *http.Request) []string {
+	return listValues(r.PostForm, q.name, q.opts)
+}
+
+func (requiredPostQueryParamArgument) isRequired() bool {
+	return true
+}
+
+func (q requiredPostQueryParamArgument) describe() string {
+	return "post query param " + q.name
 }
 
 func (q requiredPostQueryParamArgument) getValue(w http.ResponseWriter, r *http.Request) (reflect.Value, error) {
-	value := r.PostForm.Get(q.name)
+	value, _ := q.value(r)
 	if len(value) == 0 {
 		m := fmt.Sprintf("missing required post query param %s", q.name)
 		return reflect.Value{}, Error(http.StatusBadRequest, m, m)
@@ -407,13 +516,14 @@ func (q requiredPostQueryParamArgument) getValue(w http.ResponseWriter, r *http.
 	return reflect.ValueOf(value), nil
 }
 
-// RequiredPostQueryParam reads a post query param and passes it as a string. Returns 400 BAD REQUEST if empty.
-func RequiredPostQueryParam(name string) EndpointParam {
-	return requiredPostQueryParamArgument{name: name}
+// RequiredPostQueryParam reads a post query param and passes it as a string or converted to the argument's type. Returns 400 BAD REQUEST if empty.
+func RequiredPostQueryParam(name string, options ...ParamOption) EndpointParam {
+	return requiredPostQueryParamArgument{name: name, opts: newParamOptions(options)}
 }
 
 type postQueryParamArgument struct {
 	name string
+	opts paramOptions
 }
 
 func (postQueryParamArgument) options() endpointOptions {
@@ -421,23 +531,38 @@ func (postQueryParamArgument) options() endpointOptions {
 }
 
 func (p postQueryParamArgument) checkArg(arg reflect.Type) error {
-	if arg.Kind() != reflect.String {
-		return errors.New("expected a string type")
-	}
-	return nil
+	return checkListArg(arg)
+}
+
+func (p postQueryParamArgument) value(r *http.Request) (string, bool) {
+	return paramValue(r.PostForm[p.name], p.opts)
+}
+
+func (p postQueryParamArgument) lookup(r *http.Request) []string {
+	return listValues(r.PostForm, p.name, p.opts)
+}
+
+func (postQueryParamArgument) isRequired() bool {
+	return false
+}
+
+func (p postQueryParamArgument) describe() string {
+	return "post query param " + p.name
 }
 
 func (p postQueryParamArgument) getValue(w http.ResponseWriter, r *http.Request) (reflect.Value, error) {
-	return reflect.ValueOf(r.PostForm.Get(p.name)), nil
+	value, _ := p.value(r)
+	return reflect.ValueOf(value), nil
 }
 
-// PostQueryParam parses query end passes post query param into a string as an argument
-func PostQueryParam(name string) EndpointParam {
-	return postQueryParamArgument{name: name}
+// PostQueryParam parses query end passes post query param as a string or converted to the argument's type
+func PostQueryParam(name string, options ...ParamOption) EndpointParam {
+	return postQueryParamArgument{name: name, opts: newParamOptions(options)}
 }
 
 type cookieArgument struct {
 	name string
+	opts paramOptions
 }
 
 func (cookieArgument) options() endpointOptions {
@@ -445,27 +570,38 @@ func (cookieArgument) options() endpointOptions {
 }
 
 func (c cookieArgument) checkArg(arg reflect.Type) error {
-	if arg.Kind() != reflect.String {
-		return errors.New("expected a string type")
-	}
-	return nil
+	return checkStringArg(arg)
 }
 
-func (c cookieArgument) getValue(w http.ResponseWriter, r *http.Request) (reflect.Value, error) {
+func (c cookieArgument) describe() string {
+	return "cookie " + c.name
+}
+
+func (c cookieArgument) value(r *http.Request) (string, bool) {
 	cookie, err := r.Cookie(c.name)
 	if err != nil {
-		return reflect.ValueOf(""), nil
+		return paramValue(nil, c.opts)
 	}
-	return reflect.ValueOf(cookie.Value), nil
+	return cookie.Value, true
 }
 
-// Cookie reads a cookie from the request and passes it as a string
-func Cookie(name string) EndpointParam {
-	return cookieArgument{name: name}
+func (cookieArgument) isRequired() bool {
+	return false
+}
+
+func (c cookieArgument) getValue(w http.ResponseWriter, r *http.Request) (reflect.Value, error) {
+	value, _ := c.value(r)
+	return reflect.ValueOf(value), nil
+}
+
+// Cookie reads a cookie from the request and passes it as a string or converted to the argument's type
+func Cookie(name string, options ...ParamOption) EndpointParam {
+	return cookieArgument{name: name, opts: newParamOptions(options)}
 }
 
 type requiredCookieArgument struct {
 	name string
+	opts paramOptions
 }
 
 func (requiredCookieArgument) options() endpointOptions {
@@ -473,24 +609,37 @@ func (requiredCookieArgument) options() endpointOptions {
 }
 
 func (c requiredCookieArgument) checkArg(arg reflect.Type) error {
-	if arg.Kind() != reflect.String {
-		return errors.New("expected a string type")
-	}
-	return nil
+	return checkStringArg(arg)
 }
 
-func (c requiredCookieArgument) getValue(w http.ResponseWriter, r *http.Request) (reflect.Value, error) {
+func (c requiredCookieArgument) describe() string {
+	return "cookie " + c.name
+}
+
+func (c requiredCookieArgument) value(r *http.Request) (string, bool) {
 	cookie, err := r.Cookie(c.name)
 	if err != nil {
+		return paramValue(nil, c.opts)
+	}
+	return cookie.Value, true
+}
+
+func (requiredCookieArgument) isRequired() bool {
+	return true
+}
+
+func (c requiredCookieArgument) getValue(w http.ResponseWriter, r *http.Request) (reflect.Value, error) {
+	value, ok := c.value(r)
+	if !ok {
 		msg := fmt.Sprintf("missing cookie %s", c.name)
 		return reflect.Value{}, Error(http.StatusBadRequest, msg, msg)
 	}
-	return reflect.ValueOf(cookie.Value), nil
+	return reflect.ValueOf(value), nil
 }
 
-// RequiredCookie reads a cookie from the request and passes it as a string
-func RequiredCookie(name string) EndpointParam {
-	return requiredCookieArgument{name: name}
+// RequiredCookie reads a cookie from the request and passes it as a string or converted to the argument's type
+func RequiredCookie(name string, options ...ParamOption) EndpointParam {
+	return requiredCookieArgument{name: name, opts: newParamOptions(options)}
 }
 
 type headerSetterArgument struct{}
@@ -649,6 +798,9 @@ func constructStruct(structType reflect.Type, args []Argument, w http.ResponseWr
 		}
 		vStruct.Field(i).Set(fieldValue)
 	}
+	if err := validate(vPtr); err != nil {
+		return reflect.Value{}, err
+	}
 	return vPtr, nil
 }
 
@@ -659,7 +811,7 @@ func requestStruct(structType reflect.Type) (tagStructArgument, error) {
 
 	flags := flagArgument
 	numFields := structType.NumField()
-	numReadsBody := 0
+	var readsBody bodyReaders
 	var arguments []Argument
 
 	for i := 0; i < numFields; i++ {
@@ -679,20 +831,23 @@ func requestStruct(structType reflect.Type) (tagStructArgument, error) {
 		if err := fieldArg.checkArg(f.Type); err != nil {
 			return tagStructArgument{}, fmt.Errorf("(struct field %s) %w", f.Name, err)
 		}
+		fieldArg = bindArgument(fieldArg, f.Type)
 
 		fieldOpts := fieldArg.(EndpointParam).options()
-		if fieldOpts.has(flagReadsRequestBody) {
-			numReadsBody++
-		}
+		readsBody.add(fieldOpts)
 
 		flags |= fieldOpts
 		arguments = append(arguments, fieldArg)
 	}
 
-	if numReadsBody > 1 {
+	if readsBody.count > 1 {
 		return tagStructArgument{}, errors.New("only one struct field can read request's body")
 	}
 
+	if err := checkValidation(structType); err != nil {
+		return tagStructArgument{}, err
+	}
+
 	return tagStructArgument{
 		structType: structType,
 		arguments:  arguments,
@@ -0,0 +1,298 @@
+package smartapi
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+)
+
+const formContentType = "application/x-www-form-urlencoded"
+
+type bodyDecoder func(r *http.Request, v interface{}) error
+
+// maxBodyReader limits the size of request's body with http.MaxBytesReader and remembers if the limit was exceeded
+type maxBodyReader struct {
+	io.ReadCloser
+	limit    int64
+	read     int64
+	exceeded bool
+}
+
+func (m *maxBodyReader) Read(p []byte) (int, error) {
+	n, err := m.ReadCloser.Read(p)
+	m.read += int64(n)
+	if err != nil && err != io.EOF && m.read >= m.limit {
+		m.exceeded = true
+	}
+	return n, err
+}
+
+// limitBody limits the size of request's body to endpoint's MaxBodySize
+func limitBody(w http.ResponseWriter, r *http.Request) {
+	limit := configOf(r).maxBodySize
+	if limit <= 0 || r.Body == nil {
+		return
+	}
+	r.Body = &maxBodyReader{ReadCloser: http.MaxBytesReader(w, r.Body, limit), limit: limit}
+}
+
+// bodyTooLarge checks if request's body exceeded endpoint's MaxBodySize
+func bodyTooLarge(r *http.Request) bool {
+	body, ok := r.Body.(*maxBodyReader)
+	return ok && body.exceeded
+}
+
+func bodyTooLargeError(r *http.Request) ApiError {
+	msg := fmt.Sprintf("request body exceeds the limit of %d bytes", configOf(r).maxBodySize)
+	return Error(http.StatusRequestEntityTooLarge, msg, msg)
+}
+
+// bodyError converts an error of reading request's body into an API error
+func bodyError(r *http.Request, err error, reason string) error {
+	if bodyTooLarge(r) {
+		return bodyTooLargeError(r)
+	}
+	var apiErr ApiError
+	if errors.As(err, &apiErr) {
+		return err
+	}
+	return WrapError(http.StatusBadRequest, err, reason)
+}
+
+// MaxBodySize makes requests with a body larger than the limit to fail with 413 REQUEST ENTITY TOO LARGE
+func MaxBodySize(bytes int64) EndpointParam {
+	return configOption(func(c *endpointConfig) {
+		c.maxBodySize = bytes
+	})
+}
+
+func decodeFormBody(r *http.Request, v interface{}) error {
+	if err := r.ParseForm(); err != nil {
+		return err
+	}
+	return decodeForm(r.PostForm, reflect.ValueOf(v).Elem())
+}
+
+// bodyContentTypes lists content types accepted by Body
+func bodyContentTypes() []string {
+	return append(codecs.list(), formContentType)
+}
+
+// bodyDecoderOf returns a decoder for request's Content-Type. Requests without Content-Type are decoded as json.
+func bodyDecoderOf(r *http.Request) (bodyDecoder, bool) {
+	contentType := r.Header.Get("Content-Type")
+	if len(contentType) == 0 {
+		contentType = defaultContentType
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, false
+	}
+	if mediaType == formContentType {
+		return decodeFormBody, true
+	}
+	_, codec, ok := codecs.lookup(mediaType)
+	if !ok {
+		return nil, false
+	}
+	if _, ok := codec.(jsonCodec); ok {
+		return decodeJSON, true
+	}
+	return func(r *http.Request, v interface{}) error {
+		return codec.Decode(r.Body, v)
+	}, true
+}
+
+type bodyArgument struct {
+	typ reflect.Type
+}
+
+func (a bodyArgument) options() endpointOptions {
+	return flagArgument | flagReadsRequestBody
+}
+
+func (a bodyArgument) checkArg(arg reflect.Type) error {
+	if reflect.PtrTo(a.typ) != arg {
+		return errors.New("invalid type")
+	}
+	return nil
+}
+
+func (a bodyArgument) getValue(w http.ResponseWriter, r *http.Request) (reflect.Value, error) {
+	return decodeBody(w, r, a.typ)
+}
+
+func decodeBody(w http.ResponseWriter, r *http.Request, typ reflect.Type) (reflect.Value, error) {
+	decoder, ok := bodyDecoderOf(r)
+	if !ok {
+		accepted := strings.Join(bodyContentTypes(), ", ")
+		switch r.Method {
+		case http.MethodPost:
+			w.Header().Set("Accept-Post", accepted)
+		case http.MethodPatch:
+			w.Header().Set("Accept-Patch", accepted)
+		}
+		msg := fmt.Sprintf("unsupported content type %s", r.Header.Get("Content-Type"))
+		return reflect.Value{}, Error(http.StatusUnsupportedMediaType, msg, "unsupported content type")
+	}
+
+	value := reflect.New(typ)
+	if err := decoder(r, value.Interface()); err != nil {
+		return reflect.Value{}, bodyError(r, err, "cannot unmarshal request")
+	}
+	if err := validate(value); err != nil {
+		return reflect.Value{}, err
+	}
+	return value, nil
+}
+
+// Body reads request's body and unmarshals it into a pointer to a structure.
+// The format is selected by request's Content-Type, json, xml, yaml, url encoded forms and registered codecs are supported.
+// Responds with 415 UNSUPPORTED MEDIA TYPE if the format is not supported.
+func Body(v interface{}) EndpointParam {
+	typ := reflect.TypeOf(v)
+	if err := checkValidation(typ); err != nil {
+		return errorEndpointParam{err: err}
+	}
+	return bodyArgument{typ: typ}
+}
+
+type bodyDirectArgument struct {
+	typ reflect.Type
+}
+
+func (a bodyDirectArgument) options() endpointOptions {
+	return flagArgument | flagReadsRequestBody
+}
+
+func (a bodyDirectArgument) checkArg(arg reflect.Type) error {
+	if a.typ != arg {
+		return errors.New("invalid type")
+	}
+	return nil
+}
+
+func (a bodyDirectArgument) getValue(w http.ResponseWriter, r *http.Request) (reflect.Value, error) {
+	value, err := decodeBody(w, r, a.typ)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	return value.Elem(), nil
+}
+
+// BodyDirect works like Body, but passes the structure directly (not as a pointer)
+func BodyDirect(v interface{}) EndpointParam {
+	typ := reflect.TypeOf(v)
+	if err := checkValidation(typ); err != nil {
+		return errorEndpointParam{err: err}
+	}
+	return bodyDirectArgument{typ: typ}
+}
+
+// decodeForm sets fields of a structure by form values.
+// Field names are taken from form tags, json tags or names of the fields.
+// Values are converted like query params, form tags accept the same format and default options.
+func decodeForm(values url.Values, v reflect.Value) error {
+	if v.Kind() != reflect.Struct {
+		return errors.New("form can be only decoded into a structure")
+	}
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, opts, ok, err := formField(f)
+		if err != nil {
+			return fmt.Errorf("field %s: %w", f.Name, err)
+		}
+		if !ok {
+			continue
+		}
+		if err := setFormValue(v.Field(i), values, name, opts); err != nil {
+			return fmt.Errorf("field %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func formField(f reflect.StructField) (string, paramOptions, bool, error) {
+	if len(f.PkgPath) != 0 {
+		return "", paramOptions{}, false, nil
+	}
+	if tag, ok := f.Tag.Lookup("form"); ok {
+		if tag == "-" {
+			return "", paramOptions{}, false, nil
+		}
+		name, options, err := parseParamTag(tag)
+		if err != nil {
+			return "", paramOptions{}, false, err
+		}
+		return name, newParamOptions(options), true, nil
+	}
+	name, ok := jsonFieldName(f)
+	if !ok {
+		return "", paramOptions{}, false, nil
+	}
+	if len(name) == 0 {
+		return f.Name, paramOptions{}, true, nil
+	}
+	return name, paramOptions{}, true, nil
+}
+
+// setFormValue converts values of a form field to the type of the field, absent and empty values are skipped
+func setFormValue(v reflect.Value, values url.Values, name string, opts paramOptions) error {
+	typ := v.Type()
+	if _, ok := converterOf(typ); !ok && typ.Kind() == reflect.Slice && typ != byteSliceType {
+		list := listValues(values, name, opts)
+		if len(list) == 0 {
+			return nil
+		}
+		convert, ok := converterOf(typ.Elem())
+		if !ok {
+			return fmt.Errorf("cannot convert a string to %s", typ.Elem())
+		}
+		result := reflect.MakeSlice(typ, 0, len(list))
+		for _, value := range list {
+			elem, err := convert(value)
+			if err != nil {
+				return err
+			}
+			result = reflect.Append(result, elem)
+		}
+		v.Set(result)
+		return nil
+	}
+
+	value, ok := paramValue(values[name], opts)
+	if !ok || len(value) == 0 {
+		return nil
+	}
+	if typ == byteSliceType {
+		v.SetBytes([]byte(value))
+		return nil
+	}
+	if convert, ok := converterOf(typ); ok {
+		result, err := convert(value)
+		if err != nil {
+			return err
+		}
+		v.Set(result)
+		return nil
+	}
+	if typ.Kind() == reflect.Ptr {
+		if convert, ok := converterOf(typ.Elem()); ok {
+			elem, err := convert(value)
+			if err != nil {
+				return err
+			}
+			result := reflect.New(typ.Elem())
+			result.Elem().Set(elem)
+			v.Set(result)
+			return nil
+		}
+	}
+	return fmt.Errorf("cannot convert a string to %s", typ)
+}
@@ -0,0 +1,248 @@
+package smartapi_test
+
+import (
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mmbednarek/smartapi"
+	"github.com/stretchr/testify/require"
+)
+
+type bodyTestUser struct {
+	Name string   `json:"name" xml:"name" yaml:"name"`
+	Age  int      `json:"age" xml:"age" yaml:"age"`
+	Tags []string `json:"tags" xml:"tags" yaml:"tags" form:"tag"`
+}
+
+func TestBody(t *testing.T) {
+	tests := []struct {
+		name         string
+		method       string
+		contentType  string
+		body         string
+		responseCode int
+		header       http.Header
+	}{
+		{
+			name:         "JSON",
+			method:       "POST",
+			contentType:  "application/json; charset=utf-8",
+			body:         `{"name": "John", "age": 34, "tags": ["a", "b"]}`,
+			responseCode: http.StatusNoContent,
+		},
+		{
+			name:         "No Content-Type",
+			method:       "POST",
+			body:         `{"name": "John", "age": 34, "tags": ["a", "b"]}`,
+			responseCode: http.StatusNoContent,
+		},
+		{
+			name:         "Suffix",
+			method:       "PATCH",
+			contentType:  "application/merge-patch+json",
+			body:         `{"name": "John", "age": 34, "tags": ["a", "b"]}`,
+			responseCode: http.StatusNoContent,
+		},
+		{
+			name:         "XML",
+			method:       "POST",
+			contentType:  "application/xml",
+			body:         `<user><name>John</name><age>34</age><tags>a</tags><tags>b</tags></user>`,
+			responseCode: http.StatusNoContent,
+		},
+		{
+			name:         "Form",
+			method:       "POST",
+			contentType:  "application/x-www-form-urlencoded",
+			body:         `name=John&age=34&tag=a&tag=b`,
+			responseCode: http.StatusNoContent,
+		},
+		{
+			name:         "YAML",
+			method:       "POST",
+			contentType:  "application/yaml",
+			body:         "name: John\nage: 34\ntags: [a, b]\n",
+			responseCode: http.StatusNoContent,
+		},
+		{
+			name:         "Invalid form",
+			method:       "POST",
+			contentType:  "application/x-www-form-urlencoded",
+			body:         `name=John&age=old`,
+			responseCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Unsupported POST",
+			method:       "POST",
+			contentType:  "text/csv",
+			body:         "John,34",
+			responseCode: http.StatusUnsupportedMediaType,
+			header: http.Header{"Accept-Post": []string{"application/json, application/xml, text/xml, " +
+				"application/yaml, application/x-yaml, text/yaml, application/x-www-form-urlencoded"}},
+		},
+		{
+			name:         "Unsupported PATCH",
+			method:       "PATCH",
+			contentType:  "text/csv",
+			body:         "John,34",
+			responseCode: http.StatusUnsupportedMediaType,
+			header: http.Header{"Accept-Patch": []string{"application/json, application/xml, text/xml, " +
+				"application/yaml, application/x-yaml, text/yaml, application/x-www-form-urlencoded"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := smartapi.NewRouterLogger(nil)
+			r.AddEndpoint(smartapi.MethodPost, "/test", func(u *bodyTestUser) error {
+				require.Equal(t, &bodyTestUser{Name: "John", Age: 34, Tags: []string{"a", "b"}}, u)
+				return nil
+			}, []smartapi.EndpointParam{smartapi.Body(bodyTestUser{})})
+			r.AddEndpoint(smartapi.MethodPatch, "/test", func(u bodyTestUser) error {
+				require.Equal(t, bodyTestUser{Name: "John", Age: 34, Tags: []string{"a", "b"}}, u)
+				return nil
+			}, []smartapi.EndpointParam{smartapi.BodyDirect(bodyTestUser{})})
+
+			rr := httptest.NewRecorder()
+			req, err := http.NewRequest(tt.method, "/test", strings.NewReader(tt.body))
+			require.NoError(t, err)
+			if len(tt.contentType) != 0 {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			r.MustHandler().ServeHTTP(rr, req)
+
+			require.Equal(t, tt.responseCode, rr.Code)
+			for key, value := range tt.header {
+				require.Equal(t, value, rr.Header()[key])
+			}
+		})
+	}
+}
+
+func TestFormBody(t *testing.T) {
+	type form struct {
+		Timeout time.Duration `form:"timeout"`
+		IP      net.IP        `form:"ip"`
+		Since   *time.Time    `form:"since"`
+		IDs     []int         `form:"ids,format=csv"`
+		Scopes  []string      `form:"scope,format=brackets"`
+		Page    int           `form:"page,default=1"`
+		Limit   *int          `form:"limit"`
+	}
+
+	since := time.Date(2020, time.March, 14, 12, 0, 0, 0, time.UTC)
+	r := smartapi.NewRouterLogger(nil)
+	r.Post("/test", func(f *form) {
+		require.Equal(t, &form{
+			Timeout: 90 * time.Second,
+			IP:      net.ParseIP("10.0.0.1"),
+			Since:   &since,
+			IDs:     []int{1, 2, 3},
+			Scopes:  []string{"read", "write"},
+			Page:    1,
+		}, f)
+	}, smartapi.Body(form{}))
+
+	rr := httptest.NewRecorder()
+	body := "timeout=1m30s&ip=10.0.0.1&since=2020-03-14T12:00:00Z&ids=1,2,3&scope[]=read&scope[]=write&limit="
+	req, err := http.NewRequest("POST", "/test", strings.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.MustHandler().ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusNoContent, rr.Code)
+}
+
+func TestMaxBodySize(t *testing.T) {
+	tests := []struct {
+		name         string
+		api          func(r smartapi.Router)
+		body         string
+		responseCode int
+		responseBody string
+	}{
+		{
+			name: "Within the limit",
+			api: func(r smartapi.Router) {
+				r.Post("/test", func(body string) string {
+					return body
+				}, smartapi.StringBody(), smartapi.MaxBodySize(8))
+			},
+			body:         "12345678",
+			responseCode: http.StatusOK,
+			responseBody: "12345678",
+		},
+		{
+			name: "StringBody",
+			api: func(r smartapi.Router) {
+				r.Post("/test", func(string) {}, smartapi.StringBody(), smartapi.MaxBodySize(8))
+			},
+			body:         "123456789",
+			responseCode: http.StatusRequestEntityTooLarge,
+			responseBody: `{"status":413,"reason":"request body exceeds the limit of 8 bytes"}` + "\n",
+		},
+		{
+			name: "JSONBody on route",
+			api: func(r smartapi.Router) {
+				r.Route("/", func(r smartapi.Router) {
+					r.Post("/test", func(*bodyTestUser) {}, smartapi.JSONBody(bodyTestUser{}))
+				}, smartapi.MaxBodySize(8))
+			},
+			body:         `{"name":"John"}`,
+			responseCode: http.StatusRequestEntityTooLarge,
+			responseBody: `{"status":413,"reason":"request body exceeds the limit of 8 bytes"}` + "\n",
+		},
+		{
+			name: "Router default",
+			api: func(r smartapi.Router) {
+				r.Defaults(smartapi.MaxBodySize(8))
+				r.Post("/test", func(bodyTestUser) {}, smartapi.BodyDirect(bodyTestUser{}))
+			},
+			body:         `{"name":"John"}`,
+			responseCode: http.StatusRequestEntityTooLarge,
+			responseBody: `{"status":413,"reason":"request body exceeds the limit of 8 bytes"}` + "\n",
+		},
+		{
+			name: "Endpoint overrides default",
+			api: func(r smartapi.Router) {
+				r.Defaults(smartapi.MaxBodySize(8))
+				r.Post("/test", func([]byte) {}, smartapi.ByteSliceBody(), smartapi.MaxBodySize(64))
+			},
+			body:         `{"name":"John"}`,
+			responseCode: http.StatusNoContent,
+		},
+		{
+			name: "Error returned by handler",
+			api: func(r smartapi.Router) {
+				r.Post("/test", func(body io.Reader) error {
+					_, err := ioutil.ReadAll(body)
+					return err
+				}, smartapi.BodyReader(), smartapi.MaxBodySize(8))
+			},
+			body:         "123456789",
+			responseCode: http.StatusRequestEntityTooLarge,
+			responseBody: `{"status":413,"reason":"request body exceeds the limit of 8 bytes"}` + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := smartapi.NewRouterLogger(nil)
+			tt.api(r)
+
+			rr := httptest.NewRecorder()
+			req, err := http.NewRequest("POST", "/test", strings.NewReader(tt.body))
+			require.NoError(t, err)
+			r.MustHandler().ServeHTTP(rr, req)
+
+			require.Equal(t, tt.responseCode, rr.Code)
+			require.Equal(t, tt.responseBody, rr.Body.String())
+		})
+	}
+}
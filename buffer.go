@@ -0,0 +1,119 @@
+package smartapi
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+// maxPooledBuffer is the capacity of the largest buffer returned to the pool
+const maxPooledBuffer = 1 << 20
+
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		return new(bytes.Buffer)
+	},
+}
+
+// BufferedResponse makes the response to be kept in memory until the handler finishes.
+// The status and Content-Length are sent with the complete body, so encoding errors and recovered panics result in a clean error response.
+// Streamed readers and channels are never buffered.
+func BufferedResponse() EndpointParam {
+	return configOption(func(c *endpointConfig) {
+		c.bufferedResponse = true
+	})
+}
+
+// bufferedResponseWriter keeps the status and the body of a response in a pooled buffer until it's committed
+type bufferedResponseWriter struct {
+	w      http.ResponseWriter
+	status int
+	buffer *bytes.Buffer
+	header http.Header
+}
+
+func newBufferedResponseWriter(w http.ResponseWriter) *bufferedResponseWriter {
+	buffer := bufferPool.Get().(*bytes.Buffer)
+	buffer.Reset()
+	return &bufferedResponseWriter{w: w, buffer: buffer}
+}
+
+func (b *bufferedResponseWriter) Header() http.Header {
+	return b.w.Header()
+}
+
+func (b *bufferedResponseWriter) WriteHeader(status int) {
+	if b.status == 0 {
+		b.status = status
+	}
+}
+
+func (b *bufferedResponseWriter) Write(p []byte) (int, error) {
+	if b.status == 0 {
+		b.status = http.StatusOK
+	}
+	return b.buffer.Write(p)
+}
+
+// snapshot saves the headers set before the handler is called, so headers set by the handler can be discarded by reset
+func (b *bufferedResponseWriter) snapshot() {
+	b.header = b.w.Header().Clone()
+}
+
+// reset discards the status, the body and the headers of a successful response written so far.
+// Headers set while resolving arguments are kept.
+func (b *bufferedResponseWriter) reset() {
+	b.status = 0
+	b.buffer.Reset()
+	header := b.w.Header()
+	if b.header != nil {
+		for key := range header {
+			delete(header, key)
+		}
+		for key, values := range b.header.Clone() {
+			header[key] = values
+		}
+	}
+	header.Del("Content-Type")
+	header.Del("Content-Length")
+}
+
+// commit sends the status, Content-Length and the body to the client
+func (b *bufferedResponseWriter) commit() error {
+	status := b.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	if bodyAllowed(status) {
+		b.w.Header().Set("Content-Length", strconv.Itoa(b.buffer.Len()))
+	}
+	b.w.WriteHeader(status)
+	_, err := b.w.Write(b.buffer.Bytes())
+	return err
+}
+
+// release returns the buffer to the pool
+func (b *bufferedResponseWriter) release() {
+	if b.buffer.Cap() <= maxPooledBuffer {
+		bufferPool.Put(b.buffer)
+	}
+	b.buffer = nil
+}
+
+func bodyAllowed(status int) bool {
+	return status >= http.StatusOK && status != http.StatusNoContent && status != http.StatusNotModified
+}
+
+// serveBuffered calls serve with a buffered response writer and commits the response
+func serveBuffered(w http.ResponseWriter, r *http.Request, logger Logger, serve func(w http.ResponseWriter)) {
+	buffered := newBufferedResponseWriter(w)
+	defer buffered.release()
+
+	serve(buffered)
+
+	if err := buffered.commit(); err != nil && logger != nil {
+		logger.LogError(r.Context(), fmt.Errorf("cannot write response: %w", err))
+	}
+}
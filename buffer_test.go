@@ -0,0 +1,178 @@
+package smartapi
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// partialCodec writes a part of the response before failing, errors are encoded as json
+type partialCodec struct{}
+
+func (partialCodec) Encode(w io.Writer, v interface{}) error {
+	if _, ok := v.(errorResponse); ok {
+		return json.NewEncoder(w).Encode(v)
+	}
+	if _, err := w.Write([]byte(`{"name":`)); err != nil {
+		return err
+	}
+	return errors.New("encoding failed")
+}
+
+func (partialCodec) Decode(r io.Reader, v interface{}) error {
+	return json.NewDecoder(r).Decode(v)
+}
+
+func TestBufferedResponse(t *testing.T) {
+	type user struct {
+		Name string `json:"name"`
+	}
+
+	defaultCodecs := codecs
+	codecs = &codecRegistry{
+		codecs:       map[string]Codec{},
+		contentTypes: nil,
+	}
+	for _, contentType := range defaultCodecs.list() {
+		_, codec, _ := defaultCodecs.lookup(contentType)
+		RegisterCodec(contentType, codec)
+	}
+	RegisterCodec("application/partial", partialCodec{})
+	defer func() {
+		codecs = defaultCodecs
+	}()
+
+	tests := []struct {
+		name         string
+		api          func(r Router)
+		accept       string
+		responseCode int
+		responseBody string
+		header       http.Header
+	}{
+		{
+			name: "Content-Length",
+			api: func(r Router) {
+				r.Get("/test", func(h Headers) *user {
+					h.Set("X-Id", "1")
+					return &user{Name: "John"}
+				}, ResponseHeaders(), BufferedResponse())
+			},
+			responseCode: http.StatusOK,
+			responseBody: `{"name":"John"}` + "\n",
+			header: http.Header{
+				"Content-Length": {"16"},
+				"Content-Type":   {"application/json"},
+				"X-Id":           {"1"},
+			},
+		},
+		{
+			name: "Encoding error without buffering",
+			api: func(r Router) {
+				r.Get("/test", func() *user {
+					return &user{Name: "John"}
+				})
+			},
+			accept:       "application/partial",
+			responseCode: http.StatusOK,
+			responseBody: `{"name":{"status":500,"reason":"cannot encode response"}` + "\n",
+		},
+		{
+			name: "Encoding error",
+			api: func(r Router) {
+				r.Get("/test", func() (*user, error) {
+					return &user{Name: "John"}, nil
+				}, BufferedResponse())
+			},
+			accept:       "application/partial",
+			responseCode: http.StatusInternalServerError,
+			responseBody: `{"status":500,"reason":"cannot encode response"}` + "\n",
+			header:       http.Header{"Content-Length": {"49"}},
+		},
+		{
+			name: "Headers of failed handler",
+			api: func(r Router) {
+				r.Get("/test", func(h Headers) (*user, error) {
+					h.Set("Location", "/users/1")
+					h.Set("Cache-Control", "max-age=60")
+					return nil, Error(http.StatusConflict, "user exists", "user exists")
+				}, ResponseHeaders(), BufferedResponse())
+			},
+			responseCode: http.StatusConflict,
+			responseBody: `{"status":409,"reason":"user exists"}` + "\n",
+			header: http.Header{
+				"Location":      nil,
+				"Cache-Control": nil,
+				"Content-Type":  {"application/json"},
+			},
+		},
+		{
+			name: "Panic",
+			api: func(r Router) {
+				r.Defaults(BufferedResponse())
+				r.Get("/test", func(w http.ResponseWriter) {
+					_, _ = w.Write([]byte("partial"))
+					panic("failure")
+				}, ResponseWriter())
+			},
+			responseCode: http.StatusInternalServerError,
+			responseBody: `{"status":500,"reason":"internal server error"}` + "\n",
+		},
+		{
+			name: "No content",
+			api: func(r Router) {
+				r.Get("/test", func() error {
+					return nil
+				}, BufferedResponse())
+			},
+			responseCode: http.StatusNoContent,
+			header:       http.Header{"Content-Length": nil},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRouterLogger(nil)
+			tt.api(r)
+
+			rr := httptest.NewRecorder()
+			req, err := http.NewRequest("GET", "/test", nil)
+			require.NoError(t, err)
+			if len(tt.accept) != 0 {
+				req.Header.Set("Accept", tt.accept)
+			}
+			r.MustHandler().ServeHTTP(rr, req)
+
+			require.Equal(t, tt.responseCode, rr.Code)
+			require.Equal(t, tt.responseBody, rr.Body.String())
+			for key, value := range tt.header {
+				require.Equal(t, value, rr.Header()[key])
+			}
+		})
+	}
+}
+
+func TestBufferedUnsupportedContentType(t *testing.T) {
+	type user struct {
+		Name string `json:"name"`
+	}
+
+	r := NewRouterLogger(nil)
+	r.Post("/test", func(u *user) {}, Body(user{}), BufferedResponse())
+
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest("POST", "/test", strings.NewReader("John"))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "text/csv")
+	r.MustHandler().ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusUnsupportedMediaType, rr.Code)
+	require.Equal(t, strings.Join(bodyContentTypes(), ", "), rr.Header().Get("Accept-Post"))
+	require.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+}
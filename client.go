@@ -0,0 +1,416 @@
+package smartapi
+
+import (
+	"bytes"
+	"context"
+	"encoding"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Client calls endpoints of a smartapi server.
+// Client functions are defined with the same endpoint params as the server's handlers.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	errors     []error
+}
+
+// NewClient constructs a client of an api hosted at baseURL. Uses http.DefaultClient if httpClient is nil.
+func NewClient(baseURL string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: httpClient,
+	}
+}
+
+// Endpoint sets a function pointed by fnPtr to call an endpoint.
+// Function's arguments are mapped into the request by the params, the last return value must be an error.
+//
+//	var getUser func(ctx context.Context, id string) (*User, error)
+//	client.Endpoint(smartapi.MethodGet, "/user/{id}", &getUser,
+//		smartapi.Context(),
+//		smartapi.URLParam("id"),
+//	)
+func (c *Client) Endpoint(method Method, pattern string, fnPtr interface{}, params ...EndpointParam) {
+	if err := c.bind(method, pattern, fnPtr, params); err != nil {
+		c.errors = append(c.errors, fmt.Errorf("client endpoint %s %s: %w", method, pattern, err))
+	}
+}
+
+// Post sets a function to call an endpoint with a POST Method
+func (c *Client) Post(pattern string, fnPtr interface{}, params ...EndpointParam) {
+	c.Endpoint(MethodPost, pattern, fnPtr, params...)
+}
+
+// Get sets a function to call an endpoint with a GET Method
+func (c *Client) Get(pattern string, fnPtr interface{}, params ...EndpointParam) {
+	c.Endpoint(MethodGet, pattern, fnPtr, params...)
+}
+
+// Put sets a function to call an endpoint with a PUT Method
+func (c *Client) Put(pattern string, fnPtr interface{}, params ...EndpointParam) {
+	c.Endpoint(MethodPut, pattern, fnPtr, params...)
+}
+
+// Patch sets a function to call an endpoint with a PATCH Method
+func (c *Client) Patch(pattern string, fnPtr interface{}, params ...EndpointParam) {
+	c.Endpoint(MethodPatch, pattern, fnPtr, params...)
+}
+
+// Delete sets a function to call an endpoint with a DELETE Method
+func (c *Client) Delete(pattern string, fnPtr interface{}, params ...EndpointParam) {
+	c.Endpoint(MethodDelete, pattern, fnPtr, params...)
+}
+
+// Err returns errors of client function definitions
+func (c *Client) Err() error {
+	if len(c.errors) == 0 {
+		return nil
+	}
+	errMsg := c.errors[0].Error()
+	for _, e := range c.errors[1:] {
+		errMsg += ", " + e.Error()
+	}
+	return errors.New(errMsg)
+}
+
+func (c *Client) bind(method Method, pattern string, fnPtr interface{}, params []EndpointParam) error {
+	ptr := reflect.ValueOf(fnPtr)
+	if ptr.Kind() != reflect.Ptr || ptr.Elem().Kind() != reflect.Func {
+		return errors.New("expected a pointer to a function")
+	}
+	fnType := ptr.Elem().Type()
+
+	var args []Argument
+	for i, p := range params {
+		flags := p.options()
+		if flags.has(flagError) {
+			return fmt.Errorf("(argument %d) %w", i, p.(errorEndpointParam).err)
+		}
+		if flags.has(flagArgument) {
+			args = append(args, p.(Argument))
+		}
+	}
+
+	if fnType.NumIn() != len(args) {
+		return errors.New("number of arguments of a function doesn't match provided arguments")
+	}
+	var infos []argumentInfo
+	for i, a := range args {
+		if err := a.checkArg(fnType.In(i)); err != nil {
+			return fmt.Errorf("(argument %d) %w", i, err)
+		}
+		info := describeArgument(a, fnType.In(i))
+		if err := checkClientArgument(info); err != nil {
+			return fmt.Errorf("(argument %d) %w", i, err)
+		}
+		infos = append(infos, info)
+	}
+
+	if fnType.NumOut() == 0 || fnType.NumOut() > 2 || fnType.Out(fnType.NumOut()-1) != errType {
+		return errors.New("function must return an error as the last value")
+	}
+	var outType reflect.Type
+	if fnType.NumOut() == 2 {
+		outType = fnType.Out(0)
+	}
+
+	ptr.Elem().Set(reflect.MakeFunc(fnType, func(in []reflect.Value) []reflect.Value {
+		result, err := c.call(method, pattern, infos, in, outType)
+		errValue := reflect.New(errType).Elem()
+		if err != nil {
+			errValue.Set(reflect.ValueOf(err))
+		}
+		if outType == nil {
+			return []reflect.Value{errValue}
+		}
+		return []reflect.Value{result, errValue}
+	}))
+	return nil
+}
+
+// clientArgumentKinds are kinds of arguments the client can encode into a request
+var clientArgumentKinds = map[string]bool{
+	"header":             true,
+	"r_header":           true,
+	"json_body":          true,
+	"body":               true,
+	"xml_body":           true,
+	"string_body":        true,
+	"byte_slice_body":    true,
+	"body_reader":        true,
+	"url_param":          true,
+	"context":            true,
+	"query_param":        true,
+	"r_query_param":      true,
+	"post_query_param":   true,
+	"r_post_query_param": true,
+	"cookie":             true,
+	"r_cookie":           true,
+	"request_struct":     true,
+	"query_struct":       true,
+}
+
+func checkClientArgument(info argumentInfo) error {
+	if !clientArgumentKinds[info.kind] {
+		return fmt.Errorf("%s argument is not supported by the client", info.kind)
+	}
+	for _, f := range info.fields {
+		if err := checkClientArgument(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type clientRequest struct {
+	ctx         context.Context
+	pathParams  map[string]string
+	query       url.Values
+	form        url.Values
+	header      http.Header
+	cookies     []*http.Cookie
+	body        io.Reader
+	contentType string
+}
+
+func (c *Client) call(method Method, pattern string, infos []argumentInfo, in []reflect.Value, outType reflect.Type) (reflect.Value, error) {
+	var result reflect.Value
+	if outType != nil {
+		result = reflect.Zero(outType)
+	}
+
+	cr := &clientRequest{
+		ctx:        context.Background(),
+		pathParams: map[string]string{},
+		query:      url.Values{},
+		form:       url.Values{},
+		header:     http.Header{},
+	}
+	for i, info := range infos {
+		if err := cr.apply(info, in[i]); err != nil {
+			return result, err
+		}
+	}
+
+	body := cr.body
+	if body == nil && len(cr.form) > 0 {
+		body = strings.NewReader(cr.form.Encode())
+		cr.contentType = "application/x-www-form-urlencoded"
+	}
+
+	target := c.baseURL + expandPattern(pattern, cr.pathParams)
+	if len(cr.query) > 0 {
+		target += "?" + cr.query.Encode()
+	}
+
+	req, err := http.NewRequest(method.String(), target, body)
+	if err != nil {
+		return result, err
+	}
+	req = req.WithContext(cr.ctx)
+	req.Header = cr.header
+	if len(cr.contentType) != 0 {
+		req.Header.Set("Content-Type", cr.contentType)
+	}
+	for _, cookie := range cr.cookies {
+		req.AddCookie(cookie)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return result, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return result, err
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return result, responseError(method, target, resp.StatusCode, respBody)
+	}
+
+	if outType == nil || len(respBody) == 0 {
+		return result, nil
+	}
+	return decodeClientResponse(outType, respBody)
+}
+
+func (cr *clientRequest) apply(info argumentInfo, v reflect.Value) error {
+	if isParamLocation(info.location) && v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	switch info.location {
+	case locationPath:
+		cr.pathParams[info.name] = formatParam(v)
+	case locationQuery:
+		key, values := formatParamValues(info, v)
+		cr.query[key] = append(cr.query[key], values...)
+	case locationPostForm:
+		key, values := formatParamValues(info, v)
+		cr.form[key] = append(cr.form[key], values...)
+	case locationHeader:
+		key, values := formatParamValues(info, v)
+		for _, value := range values {
+			cr.header.Add(key, value)
+		}
+	case locationCookie:
+		cr.cookies = append(cr.cookies, &http.Cookie{Name: info.name, Value: formatParam(v)})
+	case locationBody:
+		return cr.setBody(info, v)
+	}
+
+	switch info.kind {
+	case "context":
+		if !v.IsNil() {
+			cr.ctx = v.Interface().(context.Context)
+		}
+	case "request_struct", "query_struct":
+		if v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return nil
+			}
+			v = v.Elem()
+		}
+		for _, f := range info.fields {
+			if err := cr.apply(f, v.FieldByIndex(f.fieldIndex)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (cr *clientRequest) setBody(info argumentInfo, v reflect.Value) error {
+	cr.contentType = info.contentType
+	switch info.kind {
+	case "json_body", "body":
+		data, err := json.Marshal(v.Interface())
+		if err != nil {
+			return err
+		}
+		cr.body = bytes.NewReader(data)
+	case "xml_body":
+		data, err := xml.Marshal(v.Interface())
+		if err != nil {
+			return err
+		}
+		cr.body = bytes.NewReader(data)
+	case "string_body":
+		cr.body = strings.NewReader(v.String())
+	case "byte_slice_body":
+		cr.body = bytes.NewReader(v.Bytes())
+	case "body_reader":
+		if !v.IsNil() {
+			cr.body = v.Interface().(io.Reader)
+		}
+	default:
+		return fmt.Errorf("%s argument is not supported by the client", info.kind)
+	}
+	return nil
+}
+
+// isParamLocation checks if values of arguments are passed as strings in the location
+func isParamLocation(location paramLocation) bool {
+	switch location {
+	case locationPath, locationQuery, locationPostForm, locationHeader, locationCookie:
+		return true
+	}
+	return false
+}
+
+// formatParamValues formats values of a param, slices are formatted in param's list format
+func formatParamValues(info argumentInfo, v reflect.Value) (string, []string) {
+	if _, ok := v.Interface().(encoding.TextMarshaler); ok || v.Kind() != reflect.Slice || v.Type() == byteSliceType {
+		return info.name, []string{formatParam(v)}
+	}
+	if v.Len() == 0 {
+		return info.name, nil
+	}
+
+	values := make([]string, v.Len())
+	for i := range values {
+		values[i] = formatParam(v.Index(i))
+	}
+	switch info.format {
+	case CommaSeparated:
+		return info.name, []string{strings.Join(values, ",")}
+	case BracketKeys:
+		return info.name + "[]", values
+	}
+	return info.name, values
+}
+
+func formatParam(v reflect.Value) string {
+	if m, ok := v.Interface().(encoding.TextMarshaler); ok {
+		if text, err := m.MarshalText(); err == nil {
+			return string(text)
+		}
+	}
+	if d, ok := v.Interface().(time.Duration); ok {
+		return d.String()
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Slice:
+		if v.Type() == byteSliceType {
+			return string(v.Bytes())
+		}
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// expandPattern replaces url params of a chi pattern with their values
+func expandPattern(pattern string, params map[string]string) string {
+	path, names := openAPIPath(pattern)
+	for _, name := range names {
+		path = strings.Replace(path, "{"+name+"}", url.PathEscape(params[name]), 1)
+	}
+	return path
+}
+
+func responseError(method Method, target string, status int, body []byte) ApiError {
+	var errResp errorResponse
+	if err := json.Unmarshal(body, &errResp); err != nil || len(errResp.Reason) == 0 {
+		errResp.Reason = http.StatusText(status)
+	}
+	return Error(status, fmt.Sprintf("%s %s: %d %s", method, target, status, errResp.Reason), errResp.Reason)
+}
+
+func decodeClientResponse(outType reflect.Type, body []byte) (reflect.Value, error) {
+	switch {
+	case outType.Kind() == reflect.String:
+		return reflect.ValueOf(string(body)).Convert(outType), nil
+	case outType == byteSliceType:
+		return reflect.ValueOf(body), nil
+	}
+
+	value := reflect.New(outType)
+	if err := json.Unmarshal(body, value.Interface()); err != nil {
+		return reflect.Zero(outType), fmt.Errorf("cannot decode response: %w", err)
+	}
+	return value.Elem(), nil
+}
@@ -0,0 +1,218 @@
+package smartapi_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mmbednarek/smartapi"
+	"github.com/stretchr/testify/require"
+)
+
+type clientTestUser struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type clientTestFilter struct {
+	Session string `smartapi:"r_header=X-Session"`
+	Name    string `smartapi:"query_param=name"`
+}
+
+func TestClient(t *testing.T) {
+	r := smartapi.NewRouter()
+	r.Get("/user/{id}", func(ctx context.Context, id int, verbose string) (*clientTestUser, error) {
+		require.Equal(t, "yes", verbose)
+		if id == 0 {
+			return nil, smartapi.Error(http.StatusNotFound, "no user", "user not found")
+		}
+		return &clientTestUser{ID: id, Name: "John"}, nil
+	},
+		smartapi.Context(),
+		smartapi.AsInt(smartapi.URLParam("id")),
+		smartapi.QueryParam("verbose"),
+	)
+	r.Post("/user", func(session string, u *clientTestUser) error {
+		require.Equal(t, "abc", session)
+		require.Equal(t, "John", u.Name)
+		return nil
+	},
+		smartapi.Cookie("session"),
+		smartapi.JSONBody(clientTestUser{}),
+		smartapi.ResponseStatus(http.StatusCreated),
+	)
+	r.Get("/search", func(f *clientTestFilter) (string, error) {
+		return f.Session + ":" + f.Name, nil
+	},
+		smartapi.RequestStruct(clientTestFilter{}),
+	)
+
+	server := httptest.NewServer(r.MustHandler())
+	defer server.Close()
+
+	client := smartapi.NewClient(server.URL, server.Client())
+
+	var getUser func(ctx context.Context, id int, verbose string) (*clientTestUser, error)
+	client.Get("/user/{id}", &getUser,
+		smartapi.Context(),
+		smartapi.AsInt(smartapi.URLParam("id")),
+		smartapi.QueryParam("verbose"),
+	)
+
+	var createUser func(session string, u *clientTestUser) error
+	client.Post("/user", &createUser,
+		smartapi.Cookie("session"),
+		smartapi.JSONBody(clientTestUser{}),
+	)
+
+	var search func(f clientTestFilter) (string, error)
+	client.Get("/search", &search,
+		smartapi.RequestStructDirect(clientTestFilter{}),
+	)
+
+	require.NoError(t, client.Err())
+
+	user, err := getUser(context.Background(), 12, "yes")
+	require.NoError(t, err)
+	require.Equal(t, &clientTestUser{ID: 12, Name: "John"}, user)
+
+	_, err = getUser(context.Background(), 0, "yes")
+	require.Error(t, err)
+	var apiErr smartapi.ApiError
+	require.True(t, errors.As(err, &apiErr))
+	require.Equal(t, http.StatusNotFound, apiErr.Status())
+	require.Equal(t, "user not found", apiErr.Reason())
+
+	require.NoError(t, createUser("abc", &clientTestUser{Name: "John"}))
+
+	result, err := search(clientTestFilter{Session: "s", Name: "n"})
+	require.NoError(t, err)
+	require.Equal(t, "s:n", result)
+}
+
+func TestClientErrors(t *testing.T) {
+	client := smartapi.NewClient("http://localhost", nil)
+
+	var noError func() string
+	client.Get("/test", &noError)
+
+	var writer func(w http.ResponseWriter) error
+	client.Get("/test", &writer, smartapi.ResponseWriter())
+
+	client.Get("/test", func() error { return nil })
+
+	var custom func(tenant string) error
+	client.Get("/test", &custom, smartapi.Custom(tenantArgument{}, 0))
+
+	var tagged func(r *struct {
+		Tenant string `smartapi:"tenant"`
+	}) error
+	client.Get("/test", &tagged, smartapi.RequestStruct(struct {
+		Tenant string `smartapi:"tenant"`
+	}{}))
+
+	require.EqualError(t, client.Err(), "client endpoint GET /test: function must return an error as the last value, "+
+		"client endpoint GET /test: (argument 0) response_writer argument is not supported by the client, "+
+		"client endpoint GET /test: expected a pointer to a function, "+
+		"client endpoint GET /test: (argument 0) custom argument is not supported by the client, "+
+		"client endpoint GET /test: (argument 0) tenant argument is not supported by the client")
+}
+
+func TestClientDurationParams(t *testing.T) {
+	r := smartapi.NewRouter()
+	r.Get("/jobs/{timeout}", func(timeout time.Duration, delay time.Duration, interval *time.Duration, retries []time.Duration) string {
+		return fmt.Sprint(timeout, delay, *interval, retries)
+	},
+		smartapi.URLParam("timeout"),
+		smartapi.QueryParam("delay"),
+		smartapi.Header("X-Interval"),
+		smartapi.QueryParam("retry", smartapi.Format(smartapi.CommaSeparated)),
+	)
+
+	server := httptest.NewServer(r.MustHandler())
+	defer server.Close()
+
+	client := smartapi.NewClient(server.URL, server.Client())
+	var getJob func(timeout time.Duration, delay time.Duration, interval *time.Duration, retries []time.Duration) (string, error)
+	client.Get("/jobs/{timeout}", &getJob,
+		smartapi.URLParam("timeout"),
+		smartapi.QueryParam("delay"),
+		smartapi.Header("X-Interval"),
+		smartapi.QueryParam("retry", smartapi.Format(smartapi.CommaSeparated)),
+	)
+	require.NoError(t, client.Err())
+
+	interval := 500 * time.Millisecond
+	result, err := getJob(time.Minute, 90*time.Second, &interval, []time.Duration{time.Second, 2 * time.Second})
+	require.NoError(t, err)
+	require.Equal(t, "1m0s 1m30s 500ms [1s 2s]", result)
+}
+
+func TestClientListParams(t *testing.T) {
+	r := smartapi.NewRouter()
+	r.Get("/search", func(tags []string, ids []int, scopes []string, page *int) string {
+		if page == nil {
+			return fmt.Sprint(tags, ids, scopes, " no page")
+		}
+		return fmt.Sprint(tags, ids, scopes, " page ", *page)
+	},
+		smartapi.QueryParam("tag", smartapi.Format(smartapi.CommaSeparated)),
+		smartapi.QueryParam("id", smartapi.Format(smartapi.BracketKeys)),
+		smartapi.Header("X-Scope"),
+		smartapi.QueryParam("page"),
+	)
+
+	server := httptest.NewServer(r.MustHandler())
+	defer server.Close()
+
+	client := smartapi.NewClient(server.URL, server.Client())
+
+	var search func(tags []string, ids []int, scopes []string, page *int) (string, error)
+	client.Get("/search", &search,
+		smartapi.QueryParam("tag", smartapi.Format(smartapi.CommaSeparated)),
+		smartapi.QueryParam("id", smartapi.Format(smartapi.BracketKeys)),
+		smartapi.Header("X-Scope"),
+		smartapi.QueryParam("page"),
+	)
+	require.NoError(t, client.Err())
+
+	result, err := search([]string{"a", "b"}, []int{1, 2}, []string{"read", "write"}, nil)
+	require.NoError(t, err)
+	require.Equal(t, "[a b] [1 2] [read write] no page", result)
+
+	page := 2
+	result, err = search(nil, nil, nil, &page)
+	require.NoError(t, err)
+	require.Equal(t, "[] [] [] page 2", result)
+}
+
+func TestClientQueryStruct(t *testing.T) {
+	r := smartapi.NewRouter()
+	r.Get("/search", func(f *queryTestFilter) string {
+		return fmt.Sprintf("%d %d %s %v %d", f.Page, *f.Limit, f.Name, f.Tags, f.Age.Min)
+	},
+		smartapi.QueryStruct(queryTestFilter{}, smartapi.RejectUnknownKeys()),
+	)
+
+	server := httptest.NewServer(r.MustHandler())
+	defer server.Close()
+
+	client := smartapi.NewClient(server.URL, server.Client())
+
+	var search func(f *queryTestFilter) (string, error)
+	client.Get("/search", &search,
+		smartapi.QueryStruct(queryTestFilter{}),
+	)
+	require.NoError(t, client.Err())
+
+	limit := 10
+	f := &queryTestFilter{queryTestPage: queryTestPage{Page: 2, Limit: &limit}, Name: "John", Tags: []string{"a", "b"}}
+	f.Age.Min = 18
+	result, err := search(f)
+	require.NoError(t, err)
+	require.Equal(t, "2 10 John [a b] 18", result)
+}
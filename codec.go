@@ -0,0 +1,213 @@
+package smartapi
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Codec encodes and decodes request and response bodies of a content type
+type Codec interface {
+	Encode(w io.Writer, v interface{}) error
+	Decode(r io.Reader, v interface{}) error
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Encode(w io.Writer, v interface{}) error {
+	return json.NewEncoder(w).Encode(v)
+}
+
+func (jsonCodec) Decode(r io.Reader, v interface{}) error {
+	return json.NewDecoder(r).Decode(v)
+}
+
+type xmlCodec struct{}
+
+func (xmlCodec) Encode(w io.Writer, v interface{}) error {
+	return xml.NewEncoder(w).Encode(v)
+}
+
+func (xmlCodec) Decode(r io.Reader, v interface{}) error {
+	return xml.NewDecoder(r).Decode(v)
+}
+
+type yamlCodec struct{}
+
+func (yamlCodec) Encode(w io.Writer, v interface{}) error {
+	return yaml.NewEncoder(w).Encode(v)
+}
+
+func (yamlCodec) Decode(r io.Reader, v interface{}) error {
+	return yaml.NewDecoder(r).Decode(v)
+}
+
+const defaultContentType = "application/json"
+
+type codecRegistry struct {
+	mutex        sync.RWMutex
+	codecs       map[string]Codec
+	contentTypes []string
+}
+
+var codecs = &codecRegistry{
+	codecs: map[string]Codec{
+		"application/json":   jsonCodec{},
+		"application/xml":    xmlCodec{},
+		"text/xml":           xmlCodec{},
+		"application/yaml":   yamlCodec{},
+		"application/x-yaml": yamlCodec{},
+		"text/yaml":          yamlCodec{},
+	},
+	contentTypes: []string{
+		"application/json",
+		"application/xml",
+		"text/xml",
+		"application/yaml",
+		"application/x-yaml",
+		"text/yaml",
+	},
+}
+
+// RegisterCodec registers a codec of a content type.
+// Registered codecs are used to decode Body params and to encode responses negotiated by the Accept header.
+func RegisterCodec(contentType string, codec Codec) {
+	codecs.mutex.Lock()
+	defer codecs.mutex.Unlock()
+	if _, ok := codecs.codecs[contentType]; !ok {
+		codecs.contentTypes = append(codecs.contentTypes, contentType)
+	}
+	codecs.codecs[contentType] = codec
+}
+
+// structuredSuffixes maps structured syntax suffixes of media types to content types of their codecs
+var structuredSuffixes = map[string]string{
+	"json": "application/json",
+	"xml":  "application/xml",
+	"yaml": "application/yaml",
+}
+
+// documentTypes have a structured syntax suffix but are documents rather than serialized data
+var documentTypes = map[string]bool{
+	"application/xhtml+xml": true,
+	"image/svg+xml":         true,
+}
+
+// lookup returns a codec of a media type and the registered content type of the codec.
+// Structured syntax suffixes like +json fall back to the codec of their base content type.
+func (c *codecRegistry) lookup(mediaType string) (string, Codec, bool) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	if codec, ok := c.codecs[mediaType]; ok {
+		return mediaType, codec, true
+	}
+	plusAt := strings.LastIndex(mediaType, "+")
+	if plusAt < 0 || documentTypes[mediaType] {
+		return "", nil, false
+	}
+	contentType, ok := structuredSuffixes[mediaType[plusAt+1:]]
+	if !ok {
+		return "", nil, false
+	}
+	codec, ok := c.codecs[contentType]
+	return contentType, codec, ok
+}
+
+func (c *codecRegistry) list() []string {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	result := make([]string, len(c.contentTypes))
+	copy(result, c.contentTypes)
+	return result
+}
+
+// negotiate selects a content type and a codec accepted by the client according to the Accept header
+func (c *codecRegistry) negotiate(accept string) (string, Codec, bool) {
+	if len(strings.TrimSpace(accept)) == 0 {
+		_, codec, _ := c.lookup(defaultContentType)
+		return defaultContentType, codec, true
+	}
+
+	for _, mediaRange := range parseAccept(accept) {
+		if mediaRange == "*/*" {
+			_, codec, _ := c.lookup(defaultContentType)
+			return defaultContentType, codec, true
+		}
+		if strings.HasSuffix(mediaRange, "/*") {
+			prefix := strings.TrimSuffix(mediaRange, "*")
+			for _, contentType := range c.list() {
+				if strings.HasPrefix(contentType, prefix) {
+					_, codec, _ := c.lookup(contentType)
+					return contentType, codec, true
+				}
+			}
+			continue
+		}
+		if contentType, codec, ok := c.lookup(mediaRange); ok {
+			return contentType, codec, true
+		}
+	}
+	return "", nil, false
+}
+
+// parseAccept returns media ranges of the Accept header ordered by their quality
+func parseAccept(accept string) []string {
+	type mediaRange struct {
+		name    string
+		quality float64
+	}
+
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			if quality, err = strconv.ParseFloat(q, 64); err != nil {
+				continue
+			}
+		}
+		if quality <= 0 {
+			continue
+		}
+		ranges = append(ranges, mediaRange{name: mediaType, quality: quality})
+	}
+
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].quality > ranges[j].quality
+	})
+
+	result := make([]string, len(ranges))
+	for i, r := range ranges {
+		result[i] = r.name
+	}
+	return result
+}
+
+// responseCodec negotiates the codec of a response. Returns 406 NOT ACCEPTABLE if none of the codecs is accepted.
+func responseCodec(r *http.Request) (string, Codec, error) {
+	contentType, codec, ok := codecs.negotiate(r.Header.Get("Accept"))
+	if !ok {
+		return "", nil, Error(http.StatusNotAcceptable, "cannot encode response as "+r.Header.Get("Accept"), "not acceptable")
+	}
+	return contentType, codec, nil
+}
+
+// errorCodec negotiates the codec of an error response, json is used if none of the codecs is accepted
+func errorCodec(r *http.Request) (string, Codec) {
+	contentType, codec, ok := codecs.negotiate(r.Header.Get("Accept"))
+	if !ok {
+		return defaultContentType, jsonCodec{}
+	}
+	return contentType, codec
+}
@@ -0,0 +1,144 @@
+package smartapi
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type upperCodec struct{}
+
+func (upperCodec) Encode(w io.Writer, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write([]byte(strings.ToUpper(string(data))))
+	return err
+}
+
+func (upperCodec) Decode(r io.Reader, v interface{}) error {
+	return json.NewDecoder(r).Decode(v)
+}
+
+func TestResponseNegotiation(t *testing.T) {
+	type user struct {
+		Name string `json:"name" xml:"name" yaml:"name"`
+	}
+
+	defaultCodecs := codecs
+	codecs = &codecRegistry{
+		codecs:       map[string]Codec{},
+		contentTypes: nil,
+	}
+	for _, contentType := range defaultCodecs.list() {
+		_, codec, _ := defaultCodecs.lookup(contentType)
+		RegisterCodec(contentType, codec)
+	}
+	RegisterCodec("application/upper", upperCodec{})
+	defer func() {
+		codecs = defaultCodecs
+	}()
+
+	tests := []struct {
+		name         string
+		accept       string
+		fail         bool
+		responseCode int
+		contentType  string
+		responseBody string
+	}{
+		{
+			name:         "Default",
+			responseCode: http.StatusOK,
+			contentType:  "application/json",
+			responseBody: `{"name":"John"}` + "\n",
+		},
+		{
+			name:         "XML",
+			accept:       "application/xml",
+			responseCode: http.StatusOK,
+			contentType:  "application/xml",
+			responseBody: `<user><name>John</name></user>`,
+		},
+		{
+			name:         "Quality",
+			accept:       "application/xml;q=0.5, text/yaml",
+			responseCode: http.StatusOK,
+			contentType:  "text/yaml",
+			responseBody: "name: John\n",
+		},
+		{
+			name:         "Wildcard",
+			accept:       "text/html, text/*",
+			responseCode: http.StatusOK,
+			contentType:  "text/xml",
+			responseBody: `<user><name>John</name></user>`,
+		},
+		{
+			name:         "Registered codec",
+			accept:       "application/upper",
+			responseCode: http.StatusOK,
+			contentType:  "application/upper",
+			responseBody: `{"NAME":"JOHN"}`,
+		},
+		{
+			name:         "Structured suffix",
+			accept:       "application/vnd.user+json",
+			responseCode: http.StatusOK,
+			contentType:  "application/json",
+			responseBody: `{"name":"John"}` + "\n",
+		},
+		{
+			name:         "Document type",
+			accept:       "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8",
+			responseCode: http.StatusOK,
+			contentType:  "application/xml",
+			responseBody: `<user><name>John</name></user>`,
+		},
+		{
+			name:         "Not acceptable",
+			accept:       "text/html",
+			responseCode: http.StatusNotAcceptable,
+			contentType:  "application/json",
+			responseBody: `{"status":406,"reason":"not acceptable"}` + "\n",
+		},
+		{
+			name:         "XML error",
+			accept:       "application/xml",
+			fail:         true,
+			responseCode: http.StatusNotFound,
+			contentType:  "application/xml",
+			responseBody: `<error><status>404</status><reason>no user</reason></error>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRouterLogger(nil)
+			r.Get("/test", func() (*user, error) {
+				if tt.fail {
+					return nil, Error(http.StatusNotFound, "no user", "no user")
+				}
+				return &user{Name: "John"}, nil
+			})
+
+			rr := httptest.NewRecorder()
+			req, err := http.NewRequest("GET", "/test", nil)
+			require.NoError(t, err)
+			if len(tt.accept) != 0 {
+				req.Header.Set("Accept", tt.accept)
+			}
+			r.MustHandler().ServeHTTP(rr, req)
+
+			require.Equal(t, tt.responseCode, rr.Code)
+			require.Equal(t, tt.contentType, rr.Header().Get("Content-Type"))
+			require.Equal(t, tt.responseBody, rr.Body.String())
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package smartapi
+
+import (
+	"encoding"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+var stringType = reflect.TypeOf("")
+var durationType = reflect.TypeOf(time.Duration(0))
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// stringArgument is an argument reading a string from the request, its value is converted to the handler's argument type
+type stringArgument interface {
+	Argument
+	// describe returns the name of the source used in error messages, for example "query param page"
+	describe() string
+	// value returns the value of the param and false if the param is absent and has no default value
+	value(r *http.Request) (string, bool)
+	isRequired() bool
+}
+
+// converter converts a string read from the request into a value of an argument's type
+type converter func(value string) (reflect.Value, error)
+
+// converterOf returns a converter to a type.
+// Supported types are strings, bools, integers, floats, time.Duration and types implementing encoding.TextUnmarshaler.
+func converterOf(typ reflect.Type) (converter, bool) {
+	if typ == durationType {
+		return func(value string) (reflect.Value, error) {
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			return reflect.ValueOf(d), nil
+		}, true
+	}
+
+	if reflect.PtrTo(typ).Implements(textUnmarshalerType) {
+		return func(value string) (reflect.Value, error) {
+			v := reflect.New(typ)
+			if err := v.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(value)); err != nil {
+				return reflect.Value{}, err
+			}
+			return v.Elem(), nil
+		}, true
+	}
+
+	switch typ.Kind() {
+	case reflect.String:
+		return func(value string) (reflect.Value, error) {
+			return reflect.ValueOf(value).Convert(typ), nil
+		}, true
+	case reflect.Bool:
+		return func(value string) (reflect.Value, error) {
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			v := reflect.New(typ).Elem()
+			v.SetBool(b)
+			return v, nil
+		}, true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return func(value string) (reflect.Value, error) {
+			i, err := strconv.ParseInt(value, 10, typ.Bits())
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			v := reflect.New(typ).Elem()
+			v.SetInt(i)
+			return v, nil
+		}, true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return func(value string) (reflect.Value, error) {
+			u, err := strconv.ParseUint(value, 10, typ.Bits())
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			v := reflect.New(typ).Elem()
+			v.SetUint(u)
+			return v, nil
+		}, true
+	case reflect.Float32, reflect.Float64:
+		return func(value string) (reflect.Value, error) {
+			f, err := strconv.ParseFloat(value, typ.Bits())
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			v := reflect.New(typ).Elem()
+			v.SetFloat(f)
+			return v, nil
+		}, true
+	}
+	return nil, false
+}
+
+// checkStringArg checks if a string can be converted to the argument's type or the type it points to
+func checkStringArg(arg reflect.Type) error {
+	if _, ok := converterOf(arg); ok {
+		return nil
+	}
+	if arg.Kind() == reflect.Ptr {
+		if _, ok := converterOf(arg.Elem()); ok {
+			return nil
+		}
+	}
+	return fmt.Errorf("cannot convert a string to %s", arg)
+}
+
+// convertArgument converts a value of a string argument to the handler's argument type
+type convertArgument struct {
+	arg     stringArgument
+	typ     reflect.Type
+	convert converter
+}
+
+func (c convertArgument) options() endpointOptions {
+	return c.arg.options()
+}
+
+func (c convertArgument) checkArg(arg reflect.Type) error {
+	if arg != c.typ {
+		return fmt.Errorf("expected %s type", c.typ)
+	}
+	return nil
+}
+
+func (c convertArgument) getValue(w http.ResponseWriter, r *http.Request) (reflect.Value, error) {
+	v, err := c.arg.getValue(w, r)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	if v.Len() == 0 {
+		return reflect.Zero(c.typ), nil
+	}
+	result, err := c.convert(v.String())
+	if err != nil {
+		return reflect.Value{}, invalidParamError(c.arg.describe(), err)
+	}
+	return result, nil
+}
+
+// bindArgument binds an argument to the type of handler's argument.
+// String arguments get converted to the type, list arguments bound to slices get all values of the param
+// and pointers are nil if the param is absent. Multipart files passed as multipart.File get opened.
+func bindArgument(a Argument, typ reflect.Type) Argument {
+	if f, ok := a.(multipartFileArgument); ok {
+		f.open = typ == multipartFileType
+		return f
+	}
+	s, ok := a.(stringArgument)
+	if !ok || typ == stringType {
+		return a
+	}
+	if convert, ok := converterOf(typ); ok {
+		return convertArgument{arg: s, typ: typ, convert: convert}
+	}
+	if typ.Kind() == reflect.Ptr {
+		if convert, ok := converterOf(typ.Elem()); ok {
+			return pointerArgument{arg: s, typ: typ, convert: convert}
+		}
+	}
+	if l, ok := a.(listArgument); ok && typ.Kind() == reflect.Slice {
+		if convert, ok := converterOf(typ.Elem()); ok {
+			return sliceArgument{arg: l, typ: typ, convert: convert}
+		}
+	}
+	return a
+}
@@ -0,0 +1,147 @@
+package smartapi_test
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mmbednarek/smartapi"
+	"github.com/stretchr/testify/require"
+)
+
+type convertTestColor string
+
+func TestConversion(t *testing.T) {
+	tests := []struct {
+		name         string
+		api          func(r smartapi.Router)
+		request      func() *http.Request
+		responseCode int
+		responseBody string
+	}{
+		{
+			name: "Numbers",
+			api: func(r smartapi.Router) {
+				r.Get("/test/{id}", func(id int64, page uint8, ratio float32, limit int) {
+					require.Equal(t, int64(-12), id)
+					require.Equal(t, uint8(255), page)
+					require.Equal(t, float32(0.5), ratio)
+					require.Equal(t, 0, limit)
+				},
+					smartapi.URLParam("id"),
+					smartapi.QueryParam("page"),
+					smartapi.Header("X-Ratio"),
+					smartapi.QueryParam("limit"),
+				)
+			},
+			request: func() *http.Request {
+				req, _ := http.NewRequest("GET", "/test/-12?page=255", nil)
+				req.Header.Set("X-Ratio", "0.5")
+				return req
+			},
+			responseCode: http.StatusNoContent,
+		},
+		{
+			name: "Other types",
+			api: func(r smartapi.Router) {
+				r.Get("/test", func(active bool, since time.Time, timeout time.Duration, ip net.IP, color convertTestColor) {
+					require.True(t, active)
+					require.Equal(t, time.Date(2020, 3, 22, 14, 17, 34, 0, time.UTC), since.UTC())
+					require.Equal(t, 90*time.Second, timeout)
+					require.Equal(t, net.IPv4(10, 0, 0, 1).String(), ip.String())
+					require.Equal(t, convertTestColor("red"), color)
+				},
+					smartapi.QueryParam("active"),
+					smartapi.RequiredQueryParam("since"),
+					smartapi.Cookie("timeout"),
+					smartapi.RequiredHeader("X-Forwarded-For"),
+					smartapi.PostQueryParam("color"),
+				)
+			},
+			request: func() *http.Request {
+				req, _ := http.NewRequest("GET", "/test?active=true&since=2020-03-22T14:17:34Z", nil)
+				req.Header.Set("X-Forwarded-For", "10.0.0.1")
+				req.AddCookie(&http.Cookie{Name: "timeout", Value: "1m30s"})
+				req.PostForm = map[string][]string{"color": {"red"}}
+				return req
+			},
+			responseCode: http.StatusNoContent,
+		},
+		{
+			name: "Request struct",
+			api: func(r smartapi.Router) {
+				type request struct {
+					ID     uint64        `smartapi:"url_param=id"`
+					Active bool          `smartapi:"r_query_param=active"`
+					Delay  time.Duration `smartapi:"header=X-Delay"`
+				}
+				r.Get("/test/{id}", func(rq request) {
+					require.Equal(t, request{ID: 12, Active: true, Delay: time.Second}, rq)
+				},
+					smartapi.RequestStructDirect(request{}),
+				)
+			},
+			request: func() *http.Request {
+				req, _ := http.NewRequest("GET", "/test/12?active=1", nil)
+				req.Header.Set("X-Delay", "1s")
+				return req
+			},
+			responseCode: http.StatusNoContent,
+		},
+		{
+			name: "Invalid integer",
+			api: func(r smartapi.Router) {
+				r.Get("/test", func(page int) {}, smartapi.QueryParam("page"))
+			},
+			request: func() *http.Request {
+				req, _ := http.NewRequest("GET", "/test?page=first", nil)
+				return req
+			},
+			responseCode: http.StatusBadRequest,
+			responseBody: `{"status":400,"reason":"invalid value of query param page"}` + "\n",
+		},
+		{
+			name: "Overflow",
+			api: func(r smartapi.Router) {
+				r.Get("/test/{id}", func(id int8) {}, smartapi.URLParam("id"))
+			},
+			request: func() *http.Request {
+				req, _ := http.NewRequest("GET", "/test/128", nil)
+				return req
+			},
+			responseCode: http.StatusBadRequest,
+			responseBody: `{"status":400,"reason":"invalid value of url param id"}` + "\n",
+		},
+		{
+			name: "Invalid time",
+			api: func(r smartapi.Router) {
+				type request struct {
+					Since time.Time `smartapi:"header=X-Since"`
+				}
+				r.Get("/test", func(rq *request) {}, smartapi.RequestStruct(request{}))
+			},
+			request: func() *http.Request {
+				req, _ := http.NewRequest("GET", "/test", nil)
+				req.Header.Set("X-Since", "yesterday")
+				return req
+			},
+			responseCode: http.StatusBadRequest,
+			responseBody: `{"status":400,"reason":"invalid value of header X-Since"}` + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := smartapi.NewRouterLogger(nil)
+			tt.api(r)
+
+			rr := httptest.NewRecorder()
+			r.MustHandler().ServeHTTP(rr, tt.request())
+
+			require.Equal(t, tt.responseCode, rr.Code)
+			require.Equal(t, tt.responseBody, rr.Body.String())
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package smartapi
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"sync"
+)
+
+// CustomArgument is a source of an argument implemented outside of the package
+type CustomArgument interface {
+	// CheckArg checks if the argument can be passed as a value of the handler's argument type
+	CheckArg(arg reflect.Type) error
+	// Value returns the value of the argument. ApiErrors are written to the response like handler's errors.
+	Value(w http.ResponseWriter, r *http.Request) (reflect.Value, error)
+}
+
+// ArgumentFlags describe how a custom argument uses the request
+type ArgumentFlags int
+
+const (
+	// ReadsRequestBody marks an argument reading request's body. Only one argument of an endpoint can read the body.
+	ReadsRequestBody ArgumentFlags = 1 << iota
+	// ParsesQuery makes the request's form to be parsed before the argument's value is read
+	ParsesQuery
+)
+
+type customArgument struct {
+	kind  string
+	arg   CustomArgument
+	flags ArgumentFlags
+}
+
+func (a customArgument) options() endpointOptions {
+	result := flagArgument
+	if a.flags&ReadsRequestBody != 0 {
+		result |= flagReadsRequestBody
+	}
+	if a.flags&ParsesQuery != 0 {
+		result |= flagParsesQuery
+	}
+	return result
+}
+
+func (a customArgument) checkArg(arg reflect.Type) error {
+	return a.arg.CheckArg(arg)
+}
+
+func (a customArgument) getValue(w http.ResponseWriter, r *http.Request) (reflect.Value, error) {
+	return a.arg.Value(w, r)
+}
+
+// Custom passes a value of a custom argument to a function
+func Custom(arg CustomArgument, flags ArgumentFlags) EndpointParam {
+	if arg == nil {
+		return errorEndpointParam{err: errors.New("nil custom argument")}
+	}
+	return customArgument{kind: "custom", arg: arg, flags: flags}
+}
+
+// TagFactory creates an argument of a struct field from data of its tag
+type TagFactory func(data string, fieldType reflect.Type) (EndpointParam, error)
+
+var tagFactories = struct {
+	sync.RWMutex
+	factories map[string]TagFactory
+}{
+	factories: map[string]TagFactory{},
+}
+
+// RegisterTag registers a kind of smartapi tags used by request structs.
+// Fields tagged with `smartapi:"kind=data"` get arguments created by the factory. Built-in kinds take precedence.
+func RegisterTag(kind string, factory TagFactory) {
+	tagFactories.Lock()
+	defer tagFactories.Unlock()
+	tagFactories.factories[kind] = factory
+}
+
+// customTagArgument creates an argument of a registered tag kind
+func customTagArgument(kind string, data string, fieldType reflect.Type) (Argument, error) {
+	tagFactories.RLock()
+	factory, ok := tagFactories.factories[kind]
+	tagFactories.RUnlock()
+	if !ok || factory == nil {
+		return nil, errors.New("unsupported tag")
+	}
+
+	param, err := factory(data, fieldType)
+	if err != nil {
+		return nil, err
+	}
+	if param == nil {
+		return nil, errors.New("tag factory returned nil")
+	}
+	if param.options().has(flagError) {
+		return nil, param.(errorEndpointParam).err
+	}
+	arg, ok := param.(Argument)
+	if !ok || !param.options().has(flagArgument) {
+		return nil, errors.New("tag factory must return an argument")
+	}
+	if custom, ok := arg.(customArgument); ok {
+		custom.kind = kind
+		return custom, nil
+	}
+	return arg, nil
+}
@@ -0,0 +1,215 @@
+package smartapi_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mmbednarek/smartapi"
+	"github.com/stretchr/testify/require"
+)
+
+type tenantArgument struct{}
+
+func (tenantArgument) CheckArg(arg reflect.Type) error {
+	if arg.Kind() != reflect.String {
+		return errors.New("expected a string type")
+	}
+	return nil
+}
+
+func (tenantArgument) Value(w http.ResponseWriter, r *http.Request) (reflect.Value, error) {
+	dotAt := strings.Index(r.Host, ".")
+	if dotAt <= 0 {
+		return reflect.Value{}, smartapi.Error(http.StatusBadRequest, "missing tenant", "missing tenant")
+	}
+	return reflect.ValueOf(r.Host[:dotAt]), nil
+}
+
+type formValueArgument struct {
+	name string
+}
+
+func (formValueArgument) CheckArg(arg reflect.Type) error {
+	return nil
+}
+
+func (a formValueArgument) Value(w http.ResponseWriter, r *http.Request) (reflect.Value, error) {
+	return reflect.ValueOf(r.Form.Get(a.name)), nil
+}
+
+type bodyLengthArgument struct{}
+
+func (bodyLengthArgument) CheckArg(arg reflect.Type) error {
+	return nil
+}
+
+func (bodyLengthArgument) Value(w http.ResponseWriter, r *http.Request) (reflect.Value, error) {
+	return reflect.ValueOf(r.ContentLength), nil
+}
+
+func init() {
+	smartapi.RegisterTag("tenant", func(data string, fieldType reflect.Type) (smartapi.EndpointParam, error) {
+		return smartapi.Custom(tenantArgument{}, 0), nil
+	})
+	smartapi.RegisterTag("form_value", func(data string, fieldType reflect.Type) (smartapi.EndpointParam, error) {
+		if len(data) == 0 {
+			return nil, errors.New("missing form value name")
+		}
+		return smartapi.Custom(formValueArgument{name: data}, smartapi.ParsesQuery), nil
+	})
+	smartapi.RegisterTag("upper_header", func(data string, fieldType reflect.Type) (smartapi.EndpointParam, error) {
+		return smartapi.Header(strings.ToUpper(data)), nil
+	})
+}
+
+func TestCustom(t *testing.T) {
+	tests := []struct {
+		name         string
+		api          func(r smartapi.Router)
+		request      func() *http.Request
+		responseCode int
+		responseBody string
+	}{
+		{
+			name: "Custom",
+			api: func(r smartapi.Router) {
+				r.Get("/test", func(tenant string) string {
+					return tenant
+				}, smartapi.Custom(tenantArgument{}, 0))
+			},
+			request: func() *http.Request {
+				req, _ := http.NewRequest("GET", "http://acme.example.com/test", nil)
+				return req
+			},
+			responseCode: http.StatusOK,
+			responseBody: "acme",
+		},
+		{
+			name: "Custom error",
+			api: func(r smartapi.Router) {
+				r.Get("/test", func(tenant string) string {
+					return tenant
+				}, smartapi.Custom(tenantArgument{}, 0))
+			},
+			request: func() *http.Request {
+				req, _ := http.NewRequest("GET", "http://localhost/test", nil)
+				return req
+			},
+			responseCode: http.StatusBadRequest,
+			responseBody: `{"status":400,"reason":"missing tenant"}` + "\n",
+		},
+		{
+			name: "ParsesQuery",
+			api: func(r smartapi.Router) {
+				r.Get("/test", func(value string) string {
+					return value
+				}, smartapi.Custom(formValueArgument{name: "value"}, smartapi.ParsesQuery))
+			},
+			request: func() *http.Request {
+				req, _ := http.NewRequest("GET", "/test?value=abc", nil)
+				return req
+			},
+			responseCode: http.StatusOK,
+			responseBody: "abc",
+		},
+		{
+			name: "Tags",
+			api: func(r smartapi.Router) {
+				type request struct {
+					Tenant string `smartapi:"tenant"`
+					Value  string `smartapi:"form_value=value"`
+					Header string `smartapi:"upper_header=x-test"`
+				}
+				r.Get("/test", func(rq *request) string {
+					return rq.Tenant + ":" + rq.Value + ":" + rq.Header
+				}, smartapi.RequestStruct(request{}))
+			},
+			request: func() *http.Request {
+				req, _ := http.NewRequest("GET", "http://acme.example.com/test?value=abc", nil)
+				req.Header.Set("X-Test", "header")
+				return req
+			},
+			responseCode: http.StatusOK,
+			responseBody: "acme:abc:header",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := smartapi.NewRouterLogger(nil)
+			tt.api(r)
+
+			rr := httptest.NewRecorder()
+			r.MustHandler().ServeHTTP(rr, tt.request())
+
+			require.Equal(t, tt.responseCode, rr.Code)
+			require.Equal(t, tt.responseBody, rr.Body.String())
+		})
+	}
+}
+
+func TestCustomErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		api  func(r smartapi.Router)
+		err  string
+	}{
+		{
+			name: "Nil argument",
+			api: func(r smartapi.Router) {
+				r.Get("/test", func(string) {}, smartapi.Custom(nil, 0))
+			},
+			err: "endpoint /test: (argument 0) nil custom argument",
+		},
+		{
+			name: "Invalid type",
+			api: func(r smartapi.Router) {
+				r.Get("/test", func(int) {}, smartapi.Custom(tenantArgument{}, 0))
+			},
+			err: "endpoint /test: (argument 0) expected a string type",
+		},
+		{
+			name: "Body read twice",
+			api: func(r smartapi.Router) {
+				r.Post("/test", func(int64, string) {},
+					smartapi.Custom(bodyLengthArgument{}, smartapi.ReadsRequestBody),
+					smartapi.StringBody(),
+				)
+			},
+			err: "endpoint /test: only one argument can read request's body",
+		},
+		{
+			name: "Unknown tag",
+			api: func(r smartapi.Router) {
+				type request struct {
+					Value string `smartapi:"unknown=value"`
+				}
+				r.Get("/test", func(*request) {}, smartapi.RequestStruct(request{}))
+			},
+			err: "endpoint /test: (argument 0) (struct field Value) unsupported tag",
+		},
+		{
+			name: "Tag factory error",
+			api: func(r smartapi.Router) {
+				type request struct {
+					Value string `smartapi:"form_value"`
+				}
+				r.Get("/test", func(*request) {}, smartapi.RequestStruct(request{}))
+			},
+			err: "endpoint /test: (argument 0) (struct field Value) missing form value name",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := smartapi.NewRouterLogger(nil)
+			tt.api(r)
+			_, err := r.Handler()
+			require.EqualError(t, err, tt.err)
+		})
+	}
+}
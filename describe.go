@@ -0,0 +1,159 @@
+package smartapi
+
+import (
+	"reflect"
+)
+
+type paramLocation int
+
+const (
+	locationNone paramLocation = iota
+	locationPath
+	locationQuery
+	locationPostForm
+	locationHeader
+	locationCookie
+	locationBody
+	locationFormFile
+)
+
+// argumentInfo describes the source of an argument's value
+type argumentInfo struct {
+	kind        string
+	name        string
+	required    bool
+	location    paramLocation
+	typ         reflect.Type
+	contentType string
+	fieldIndex  []int
+	fields      []argumentInfo
+	format      ListFormat
+}
+
+// describeArgument describes an argument passed to a handler as a value of type typ
+func describeArgument(a Argument, typ reflect.Type) argumentInfo {
+	switch arg := a.(type) {
+	case headerArgument:
+		return argumentInfo{kind: "header", name: arg.name, location: locationHeader, typ: typ, format: arg.opts.format}
+	case requiredHeaderArgument:
+		return argumentInfo{kind: "r_header", name: arg.name, required: true, location: locationHeader, typ: typ, format: arg.opts.format}
+	case jsonBodyArgument:
+		return argumentInfo{kind: "json_body", required: true, location: locationBody, typ: typ, contentType: "application/json"}
+	case jsonBodyDirectArgument:
+		return argumentInfo{kind: "json_body", required: true, location: locationBody, typ: typ, contentType: "application/json"}
+	case bodyArgument:
+		return argumentInfo{kind: "body", required: true, location: locationBody, typ: typ, contentType: "application/json"}
+	case bodyDirectArgument:
+		return argumentInfo{kind: "body", required: true, location: locationBody, typ: typ, contentType: "application/json"}
+	case xmlBodyArgument:
+		return argumentInfo{kind: "xml_body", required: true, location: locationBody, typ: typ, contentType: "application/xml"}
+	case stringBodyArgument:
+		return argumentInfo{kind: "string_body", location: locationBody, typ: typ, contentType: "text/plain"}
+	case byteSliceBodyArgument:
+		return argumentInfo{kind: "byte_slice_body", location: locationBody, typ: typ, contentType: "application/octet-stream"}
+	case bodyReaderArgument:
+		return argumentInfo{kind: "body_reader", location: locationBody, typ: typ, contentType: "application/octet-stream"}
+	case urlParamArgument:
+		return argumentInfo{kind: "url_param", name: arg.name, required: true, location: locationPath, typ: typ}
+	case contextArgument:
+		return argumentInfo{kind: "context", typ: typ}
+	case queryParamArgument:
+		return argumentInfo{kind: "query_param", name: arg.name, location: locationQuery, typ: typ, format: arg.opts.format}
+	case requiredQueryParamArgument:
+		return argumentInfo{kind: "r_query_param", name: arg.name, required: true, location: locationQuery, typ: typ, format: arg.opts.format}
+	case postQueryParamArgument:
+		return argumentInfo{kind: "post_query_param", name: arg.name, location: locationPostForm, typ: typ, format: arg.opts.format}
+	case requiredPostQueryParamArgument:
+		return argumentInfo{kind: "r_post_query_param", name: arg.name, required: true, location: locationPostForm, typ: typ, format: arg.opts.format}
+	case cookieArgument:
+		return argumentInfo{kind: "cookie", name: arg.name, location: locationCookie, typ: typ}
+	case requiredCookieArgument:
+		return argumentInfo{kind: "r_cookie", name: arg.name, required: true, location: locationCookie, typ: typ}
+	case headerSetterArgument:
+		return argumentInfo{kind: "response_headers", typ: typ}
+	case cookieSetterArgument:
+		return argumentInfo{kind: "response_cookies", typ: typ}
+	case responseWriterArgument:
+		return argumentInfo{kind: "response_writer", typ: typ}
+	case fullRequestArgument:
+		return argumentInfo{kind: "request", typ: typ}
+	case multipartFileArgument:
+		return argumentInfo{kind: "form_file", name: arg.name, required: true, location: locationFormFile, typ: typ}
+	case multipartFilesArgument:
+		return argumentInfo{kind: "form_files", name: arg.name, location: locationFormFile, typ: typ}
+	case multipartReaderArgument:
+		return argumentInfo{kind: "multipart_reader", location: locationBody, typ: typ, contentType: "multipart/form-data"}
+	case webSocketConnArgument:
+		return argumentInfo{kind: "websocket_conn", typ: typ}
+	case customArgument:
+		return argumentInfo{kind: arg.kind, typ: typ}
+	case convertArgument:
+		return describeArgument(arg.arg, typ)
+	case sliceArgument:
+		return describeArgument(arg.arg, typ)
+	case asIntArgument:
+		return describeArgument(arg.arg, typ)
+	case asByteSliceArgument:
+		return describeArgument(arg.arg, typ)
+	case queryStructArgument:
+		return describeQueryStruct(arg, typ)
+	case queryStructDirectArgument:
+		return describeQueryStruct(queryStructArgument(arg), typ)
+	case tagStructArgument:
+		return describeStruct(arg.structType, arg.arguments, typ)
+	case tagStructDirectArgument:
+		return describeStruct(arg.structType, arg.arguments, typ)
+	}
+	return argumentInfo{kind: "unknown", typ: typ}
+}
+
+func describeStruct(structType reflect.Type, args []Argument, typ reflect.Type) argumentInfo {
+	info := argumentInfo{kind: "request_struct", typ: typ}
+	for i, a := range args {
+		if a == nil {
+			continue
+		}
+		field := describeArgument(a, structType.Field(i).Type)
+		field.fieldIndex = []int{i}
+		info.fields = append(info.fields, field)
+	}
+	return info
+}
+
+// describeArguments describes arguments of a handler function
+func describeArguments(handler interface{}, args []Argument) []argumentInfo {
+	fnType := reflect.TypeOf(handler)
+	result := make([]argumentInfo, 0, len(args))
+	for i, a := range args {
+		result = append(result, describeArgument(a, fnType.In(i)))
+	}
+	return result
+}
+
+// flattenArguments returns descriptions of arguments with request and query structs expanded into their fields
+func flattenArguments(infos []argumentInfo) []argumentInfo {
+	var result []argumentInfo
+	for _, info := range infos {
+		if info.kind == "request_struct" || info.kind == "query_struct" {
+			result = append(result, flattenArguments(info.fields)...)
+			continue
+		}
+		result = append(result, info)
+	}
+	return result
+}
+
+func describeQueryStruct(q queryStructArgument, typ reflect.Type) argumentInfo {
+	info := argumentInfo{kind: "query_struct", typ: typ}
+	for _, f := range q.fields {
+		info.fields = append(info.fields, argumentInfo{
+			kind:       "query_param",
+			name:       f.key,
+			location:   locationQuery,
+			typ:        f.typ,
+			fieldIndex: f.index,
+			format:     q.opts.format,
+		})
+	}
+	return info
+}
@@ -1,6 +1,10 @@
 package smartapi
 
-import "fmt"
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+)
 
 // ApiError represents an API error
 type ApiError interface {
@@ -36,9 +40,90 @@ func WrapError(status int, err error, reason string) ApiError {
 	}
 }
 
+// DetailedError is an ApiError with RFC 7807 problem details and extension members.
+// Title is used as the reason of legacy error responses, extension members are written under the details key.
+type DetailedError struct {
+	StatusCode int
+	Message    string
+	Type       string
+	Title      string
+	Detail     string
+	Instance   string
+	Extensions map[string]interface{}
+}
+
+func (d *DetailedError) Error() string {
+	return d.Message
+}
+
+// Status returns status code of the error
+func (d *DetailedError) Status() int {
+	return d.StatusCode
+}
+
+// Reason returns the title of the error
+func (d *DetailedError) Reason() string {
+	return d.Title
+}
+
+// With adds an extension member to the error
+func (d *DetailedError) With(key string, value interface{}) *DetailedError {
+	if d.Extensions == nil {
+		d.Extensions = map[string]interface{}{}
+	}
+	d.Extensions[key] = value
+	return d
+}
+
+// Problem creates an error with problem details. Detail is returned to the client.
+func Problem(status int, problemType, title, detail string) *DetailedError {
+	return &DetailedError{
+		StatusCode: status,
+		Message:    detail,
+		Type:       problemType,
+		Title:      title,
+		Detail:     detail,
+	}
+}
+
+// WithDetail attaches an extension member to an error
+func WithDetail(err ApiError, key string, value interface{}) *DetailedError {
+	return detailedError(err).With(key, value)
+}
+
+// WithFieldErrors attaches a list of invalid fields to an error
+func WithFieldErrors(err ApiError, fields ...FieldError) *DetailedError {
+	return detailedError(err).With("errors", fields)
+}
+
+// WithErrorCode attaches an application specific error code to an error
+func WithErrorCode(err ApiError, code string) *DetailedError {
+	return detailedError(err).With("code", code)
+}
+
+func detailedError(err ApiError) *DetailedError {
+	var d *DetailedError
+	if errors.As(err, &d) {
+		result := *d
+		result.Extensions = make(map[string]interface{}, len(d.Extensions))
+		for k, v := range d.Extensions {
+			result.Extensions[k] = v
+		}
+		return &result
+	}
+	return &DetailedError{
+		StatusCode: err.Status(),
+		Message:    err.Error(),
+		Title:      err.Reason(),
+	}
+}
+
 type errorResponse struct {
-	Status int    `json:"status"`
-	Reason string `json:"reason"`
+	XMLName xml.Name               `json:"-" xml:"error" yaml:"-"`
+	Status  int                    `json:"status" xml:"status" yaml:"status"`
+	Reason  string                 `json:"reason" xml:"reason" yaml:"reason"`
+	Errors  []FieldError           `json:"errors,omitempty" xml:"errors,omitempty" yaml:"errors,omitempty"`
+	Details map[string]interface{} `json:"details,omitempty" xml:"-" yaml:"details,omitempty"`
 }
 
 type statusError struct {
@@ -58,3 +143,20 @@ func (s statusError) Status() int {
 func (s statusError) Error() string {
 	return s.message
 }
+
+// errorMapping converts errors matched by a predicate into API errors
+type errorMapping struct {
+	match  func(err error) bool
+	status int
+	reason string
+}
+
+// mapError converts an error using the first matching mapping
+func mapError(mappings []errorMapping, err error) (ApiError, bool) {
+	for _, m := range mappings {
+		if m.match(err) {
+			return WrapError(m.status, err, m.reason), true
+		}
+	}
+	return nil, false
+}
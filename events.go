@@ -0,0 +1,181 @@
+package smartapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultHeartbeat is the interval of comments sent to keep an idle event stream open
+const defaultHeartbeat = 15 * time.Second
+
+// Event is a server-sent event received from a channel returned by an events handler
+type Event struct {
+	// ID sets the id of the event the client sends back as Last-Event-ID after reconnecting
+	ID string
+	// Event is the type of the event, empty type results in a message event
+	Event string
+	// Data is written as it is if it's a string or a byte slice, other values are encoded into json
+	Data interface{}
+	// Retry tells the client how long to wait before reconnecting
+	Retry time.Duration
+}
+
+var eventType = reflect.TypeOf(Event{})
+var eventChanType = reflect.TypeOf((<-chan Event)(nil))
+
+var eventFieldReplacer = strings.NewReplacer("\r", "", "\n", "")
+
+// write writes the event in text/event-stream format
+func (e Event) write(w http.ResponseWriter) error {
+	var builder strings.Builder
+	if len(e.ID) != 0 {
+		builder.WriteString("id: " + eventFieldReplacer.Replace(e.ID) + "\n")
+	}
+	if len(e.Event) != 0 {
+		builder.WriteString("event: " + eventFieldReplacer.Replace(e.Event) + "\n")
+	}
+	if e.Retry > 0 {
+		builder.WriteString("retry: " + strconv.FormatInt(e.Retry.Milliseconds(), 10) + "\n")
+	}
+
+	var data string
+	switch v := e.Data.(type) {
+	case nil:
+	case string:
+		data = v
+	case []byte:
+		data = string(v)
+	default:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		data = string(encoded)
+	}
+	if e.Data != nil {
+		data = strings.ReplaceAll(data, "\r\n", "\n")
+		for _, line := range strings.Split(data, "\n") {
+			builder.WriteString("data: " + line + "\n")
+		}
+	}
+	builder.WriteString("\n")
+
+	_, err := w.Write([]byte(builder.String()))
+	return err
+}
+
+// Heartbeat sets the interval of comments sent to keep an idle event stream open and of websocket pings.
+// A websocket not answering for two intervals is closed. Zero or a negative interval disables them.
+func Heartbeat(interval time.Duration) EndpointParam {
+	if interval <= 0 {
+		interval = -1
+	}
+	return configOption(func(c *endpointConfig) {
+		c.heartbeat = interval
+	})
+}
+
+// LastEventID reads the id of the last event received by a reconnecting client
+func LastEventID() EndpointParam {
+	return Header("Last-Event-ID")
+}
+
+// eventStreamParam marks an endpoint registered with Events
+type eventStreamParam struct{}
+
+func (eventStreamParam) options() endpointOptions {
+	return flagEventStream
+}
+
+// checkEventsHandler checks if a handler returns a channel of events, optionally with an error
+func checkEventsHandler(handlerFunc interface{}, arguments []Argument, writesResponse bool) (endpointHandler, error) {
+	fnType, err := checkArguments(handlerFunc, arguments)
+	if err != nil {
+		return nil, err
+	}
+	if writesResponse {
+		return nil, errors.New("cannot write response and return events")
+	}
+	if fnType.NumOut() == 0 || fnType.NumOut() > 2 {
+		return nil, errors.New("events handler must return a channel of events")
+	}
+	out := fnType.Out(0)
+	if out.Kind() != reflect.Chan || out.ChanDir()&reflect.RecvDir == 0 || out.Elem() != eventType {
+		return nil, errors.New("events handler must return a channel of events")
+	}
+	if fnType.NumOut() == 2 && !fnType.Out(1).Implements(errType) {
+		return nil, errors.New("expect an error type in return arguments")
+	}
+	return eventsHandler{handlerFunc: handlerFunc, withError: fnType.NumOut() == 2}, nil
+}
+
+type eventsHandler struct {
+	handlerFunc interface{}
+	withError   bool
+}
+
+func (e eventsHandler) handleRequest(w http.ResponseWriter, r *http.Request, logger Logger, endpoint endpointData) {
+	attribs, err := getCallAttributes(w, r, endpoint)
+	if err != nil {
+		handleError(w, r, logger, err)
+		return
+	}
+	value := reflect.ValueOf(e.handlerFunc)
+	result := value.Call(attribs)
+
+	if e.withError && !result[1].IsNil() {
+		handleErrorValue(w, r, logger, result[1])
+		return
+	}
+	if result[0].IsNil() {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	writeEvents(w, r, logger, result[0].Convert(eventChanType).Interface().(<-chan Event))
+}
+
+// writeEvents writes events received from a channel until it's closed or the request's context is done
+func writeEvents(w http.ResponseWriter, r *http.Request, logger Logger, events <-chan Event) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flush(w)
+
+	var heartbeat <-chan time.Time
+	interval := configOf(r).heartbeat
+	if interval == 0 {
+		interval = defaultHeartbeat
+	}
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		heartbeat = ticker.C
+	}
+
+	for {
+		var err error
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat:
+			_, err = w.Write([]byte(": heartbeat\n\n"))
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			err = event.write(w)
+		}
+		if err != nil {
+			logStreamError(r, logger, fmt.Errorf("cannot write event: %w", err))
+			return
+		}
+		flush(w)
+	}
+}
@@ -0,0 +1,243 @@
+package smartapi_test
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mmbednarek/smartapi"
+	"github.com/stretchr/testify/require"
+)
+
+func eventsOf(events ...smartapi.Event) <-chan smartapi.Event {
+	ch := make(chan smartapi.Event, len(events))
+	for _, e := range events {
+		ch <- e
+	}
+	close(ch)
+	return ch
+}
+
+func TestEvents(t *testing.T) {
+	tests := []struct {
+		name         string
+		api          func(r smartapi.Router)
+		header       http.Header
+		responseCode int
+		responseBody string
+		bodyPrefix   bool
+		contentType  string
+	}{
+		{
+			name: "Events",
+			api: func(r smartapi.Router) {
+				r.Events("/test", func() <-chan smartapi.Event {
+					return eventsOf(
+						smartapi.Event{Data: "hello"},
+						smartapi.Event{ID: "2", Event: "update", Data: map[string]int{"count": 3}},
+						smartapi.Event{Retry: 5 * time.Second},
+					)
+				})
+			},
+			responseCode: http.StatusOK,
+			responseBody: "data: hello\n\nid: 2\nevent: update\ndata: {\"count\":3}\n\nretry: 5000\n\n",
+			contentType:  "text/event-stream",
+		},
+		{
+			name: "Multiline data",
+			api: func(r smartapi.Router) {
+				r.Events("/test", func() (chan smartapi.Event, error) {
+					ch := make(chan smartapi.Event, 1)
+					ch <- smartapi.Event{ID: "1\n2", Data: []byte("first\r\nsecond\nthird")}
+					close(ch)
+					return ch, nil
+				})
+			},
+			responseCode: http.StatusOK,
+			responseBody: "id: 12\ndata: first\ndata: second\ndata: third\n\n",
+			contentType:  "text/event-stream",
+		},
+		{
+			name: "LastEventID",
+			api: func(r smartapi.Router) {
+				r.Events("/test", func(lastID string) <-chan smartapi.Event {
+					return eventsOf(smartapi.Event{ID: lastID + "1"})
+				}, smartapi.LastEventID())
+			},
+			header:       http.Header{"Last-Event-ID": {"41"}},
+			responseCode: http.StatusOK,
+			responseBody: "id: 411\n\n",
+			contentType:  "text/event-stream",
+		},
+		{
+			name: "Heartbeat",
+			api: func(r smartapi.Router) {
+				r.Events("/test", func() <-chan smartapi.Event {
+					ch := make(chan smartapi.Event)
+					go func() {
+						time.Sleep(50 * time.Millisecond)
+						close(ch)
+					}()
+					return ch
+				}, smartapi.Heartbeat(20*time.Millisecond))
+			},
+			responseCode: http.StatusOK,
+			responseBody: ": heartbeat\n\n",
+			bodyPrefix:   true,
+			contentType:  "text/event-stream",
+		},
+		{
+			name: "Handler error",
+			api: func(r smartapi.Router) {
+				r.Events("/test", func() (<-chan smartapi.Event, error) {
+					return nil, smartapi.Error(http.StatusForbidden, "no access", "forbidden")
+				})
+			},
+			responseCode: http.StatusForbidden,
+			responseBody: `{"status":403,"reason":"forbidden"}` + "\n",
+			contentType:  "application/json",
+		},
+		{
+			name: "Nil channel",
+			api: func(r smartapi.Router) {
+				r.Events("/test", func() <-chan smartapi.Event {
+					return nil
+				})
+			},
+			responseCode: http.StatusNoContent,
+		},
+		{
+			name: "Not buffered",
+			api: func(r smartapi.Router) {
+				r.Events("/test", func() <-chan smartapi.Event {
+					return eventsOf(smartapi.Event{Data: "hello"})
+				}, smartapi.BufferedResponse())
+			},
+			responseCode: http.StatusOK,
+			responseBody: "data: hello\n\n",
+			contentType:  "text/event-stream",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := smartapi.NewRouterLogger(nil)
+			tt.api(r)
+
+			rr := httptest.NewRecorder()
+			req, err := http.NewRequest("GET", "/test", nil)
+			require.NoError(t, err)
+			for k, v := range tt.header {
+				req.Header.Set(k, v[0])
+			}
+			r.MustHandler().ServeHTTP(rr, req)
+
+			require.Equal(t, tt.responseCode, rr.Code)
+			if tt.bodyPrefix {
+				require.True(t, strings.HasPrefix(rr.Body.String(), tt.responseBody))
+			} else {
+				require.Equal(t, tt.responseBody, rr.Body.String())
+			}
+			require.Equal(t, tt.contentType, rr.Header().Get("Content-Type"))
+		})
+	}
+}
+
+func TestEventsErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		api    func(r smartapi.Router)
+		expect error
+	}{
+		{
+			name: "No return value",
+			api: func(r smartapi.Router) {
+				r.Events("/test", func() {})
+			},
+			expect: errors.New("endpoint /test: events handler must return a channel of events"),
+		},
+		{
+			name: "Channel of strings",
+			api: func(r smartapi.Router) {
+				r.Events("/test", func() <-chan string { return nil })
+			},
+			expect: errors.New("endpoint /test: events handler must return a channel of events"),
+		},
+		{
+			name: "Send only channel",
+			api: func(r smartapi.Router) {
+				r.Events("/test", func() chan<- smartapi.Event { return nil })
+			},
+			expect: errors.New("endpoint /test: events handler must return a channel of events"),
+		},
+		{
+			name: "Second value is not an error",
+			api: func(r smartapi.Router) {
+				r.Events("/test", func() (<-chan smartapi.Event, int) { return nil, 0 })
+			},
+			expect: errors.New("endpoint /test: expect an error type in return arguments"),
+		},
+		{
+			name: "Writes response",
+			api: func(r smartapi.Router) {
+				r.Events("/test", func(http.ResponseWriter) <-chan smartapi.Event { return nil }, smartapi.ResponseWriter())
+			},
+			expect: errors.New("endpoint /test: cannot write response and return events"),
+		},
+		{
+			name: "Invalid argument",
+			api: func(r smartapi.Router) {
+				r.Events("/test", func(struct{}) <-chan smartapi.Event { return nil }, smartapi.LastEventID())
+			},
+			expect: errors.New("endpoint /test: (argument 0) cannot convert a string to struct {}"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := smartapi.NewRouterLogger(nil)
+			tt.api(r)
+			_, err := r.Handler()
+			require.Equal(t, tt.expect, err)
+		})
+	}
+}
+
+func TestEventsClientDisconnect(t *testing.T) {
+	done := make(chan struct{})
+	r := smartapi.NewRouterLogger(nil)
+	r.Events("/test", func(ctx context.Context) <-chan smartapi.Event {
+		ch := make(chan smartapi.Event)
+		go func() {
+			defer close(done)
+			for {
+				select {
+				case ch <- smartapi.Event{Data: "tick"}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+		return ch
+	}, smartapi.Context())
+
+	server := httptest.NewServer(r.MustHandler())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/test")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "no-cache", resp.Header.Get("Cache-Control"))
+
+	line, err := bufio.NewReader(resp.Body).ReadString('\n')
+	require.NoError(t, err)
+	require.Equal(t, "data: tick\n", line)
+	require.NoError(t, resp.Body.Close())
+
+	<-done
+}
@@ -50,6 +50,9 @@ type Order struct {
 
 // Init inits the api
 func (a *API) Init() {
+	a.MapError(ErrUserDoesNotExists, http.StatusNotFound, "user does not exists")
+	a.MapError(ErrUserAlreadyExists, http.StatusBadRequest, "user already exists")
+
 	a.With(middleware.DefaultLogger).Route("/user", func(r smartapi.Router) {
 		r.Get("/", a.GetUser,
 			smartapi.QueryParam("user"),
@@ -95,23 +98,10 @@ func (a *API) Init() {
 
 // GetUser handles the user endpoint
 func (a *API) GetUser(ctx context.Context, name string) (*UserData, error) {
-	user, err := a.storage.GetUser(name)
-	if err != nil {
-		if errors.Is(err, ErrUserDoesNotExists) {
-			return nil, smartapi.WrapError(http.StatusNotFound, err, "user does not exists")
-		}
-		return nil, err
-	}
-	return user, nil
+	return a.storage.GetUser(name)
 }
 
 // NewUser handles the POST user endpoint
 func (a *API) NewUser(ctx context.Context, userID string, userData *UserData) error {
-	if err := a.storage.StoreUser(userID, userData); err != nil {
-		if errors.Is(err, ErrUserAlreadyExists) {
-			return smartapi.WrapError(http.StatusBadRequest, err, "user already exists")
-		}
-		return err
-	}
-	return nil
+	return a.storage.StoreUser(userID, userData)
 }
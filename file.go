@@ -0,0 +1,92 @@
+package smartapi
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"reflect"
+	"time"
+)
+
+// File is served with support of range requests and conditional requests
+type File struct {
+	// Name is used to detect the content type by its extension and as the file name of an attachment
+	Name string
+	// ModTime is sent as Last-Modified and checked against If-Modified-Since and If-Unmodified-Since, zero time is ignored
+	ModTime time.Time
+	// Content is read from the beginning, it's closed if it's an io.Closer
+	Content io.ReadSeeker
+	// ContentType overrides the content type detected by the name or by sniffing the content
+	ContentType string
+	// ETag is sent with the file and checked against If-Match, If-None-Match and If-Range
+	ETag string
+	// Attachment makes the client download the file instead of displaying it
+	Attachment bool
+}
+
+var fileType = reflect.TypeOf(File{})
+var filePtrType = reflect.TypeOf(&File{})
+
+// isFileType checks if a handler returns a file
+func isFileType(typ reflect.Type) bool {
+	return typ == fileType || typ == filePtrType
+}
+
+// writeFile serves the file, nil file results in no content
+func writeFile(w http.ResponseWriter, r *http.Request, file *File) {
+	if file == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	content := file.Content
+	if content == nil {
+		content = bytes.NewReader(nil)
+	}
+	if closer, ok := content.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	if len(file.ContentType) != 0 {
+		w.Header().Set("Content-Type", file.ContentType)
+	}
+	if len(file.ETag) != 0 {
+		w.Header().Set("ETag", file.ETag)
+	}
+	if file.Attachment {
+		params := map[string]string{}
+		if name := path.Base(file.Name); len(file.Name) != 0 && name != "/" && name != "." {
+			params["filename"] = name
+		}
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", params))
+	}
+	http.ServeContent(w, r, file.Name, file.ModTime, content)
+}
+
+type fileHandler struct {
+	handlerFunc interface{}
+	withError   bool
+}
+
+func (f fileHandler) handleRequest(w http.ResponseWriter, r *http.Request, logger Logger, endpoint endpointData) {
+	attribs, err := getCallAttributes(w, r, endpoint)
+	if err != nil {
+		handleError(w, r, logger, err)
+		return
+	}
+	value := reflect.ValueOf(f.handlerFunc)
+	result := value.Call(attribs)
+
+	if f.withError && !result[1].IsNil() {
+		handleErrorValue(w, r, logger, result[1])
+		return
+	}
+
+	switch file := result[0].Interface().(type) {
+	case File:
+		writeFile(w, r, &file)
+	case *File:
+		writeFile(w, r, file)
+	}
+}
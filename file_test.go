@@ -0,0 +1,227 @@
+package smartapi_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mmbednarek/smartapi"
+	"github.com/stretchr/testify/require"
+)
+
+type fileTestContent struct {
+	*strings.Reader
+	closed bool
+}
+
+func (f *fileTestContent) Close() error {
+	f.closed = true
+	return nil
+}
+
+func TestFile(t *testing.T) {
+	modTime := time.Date(2020, time.March, 14, 12, 0, 0, 0, time.UTC)
+	content := &fileTestContent{Reader: strings.NewReader("a,b\n1,2\n")}
+
+	tests := []struct {
+		name         string
+		api          func(r smartapi.Router)
+		method       string
+		header       http.Header
+		responseCode int
+		responseBody string
+		checkHeader  func(h http.Header)
+	}{
+		{
+			name: "File",
+			api: func(r smartapi.Router) {
+				r.Get("/test", func() smartapi.File {
+					return smartapi.File{Name: "report.csv", ModTime: modTime, Content: strings.NewReader("a,b\n1,2\n")}
+				})
+			},
+			responseCode: http.StatusOK,
+			responseBody: "a,b\n1,2\n",
+			checkHeader: func(h http.Header) {
+				require.Equal(t, "text/csv; charset=utf-8", h.Get("Content-Type"))
+				require.Equal(t, "8", h.Get("Content-Length"))
+				require.Equal(t, "Sat, 14 Mar 2020 12:00:00 GMT", h.Get("Last-Modified"))
+				require.Equal(t, "bytes", h.Get("Accept-Ranges"))
+				require.Empty(t, h.Get("Content-Disposition"))
+			},
+		},
+		{
+			name: "Content sniffing",
+			api: func(r smartapi.Router) {
+				r.Get("/test", func() (*smartapi.File, error) {
+					return &smartapi.File{Content: strings.NewReader("<html><body>Hello</body></html>")}, nil
+				})
+			},
+			responseCode: http.StatusOK,
+			responseBody: "<html><body>Hello</body></html>",
+			checkHeader: func(h http.Header) {
+				require.Equal(t, "text/html; charset=utf-8", h.Get("Content-Type"))
+			},
+		},
+		{
+			name: "Attachment",
+			api: func(r smartapi.Router) {
+				r.Get("/test", func() (smartapi.File, error) {
+					return smartapi.File{
+						Name:        "reports/march report.csv",
+						Content:     content,
+						ContentType: "application/vnd.ms-excel",
+						Attachment:  true,
+					}, nil
+				})
+			},
+			responseCode: http.StatusOK,
+			responseBody: "a,b\n1,2\n",
+			checkHeader: func(h http.Header) {
+				require.Equal(t, "application/vnd.ms-excel", h.Get("Content-Type"))
+				require.Equal(t, `attachment; filename="march report.csv"`, h.Get("Content-Disposition"))
+			},
+		},
+		{
+			name: "Range",
+			api: func(r smartapi.Router) {
+				r.Get("/test", func() smartapi.File {
+					return smartapi.File{Name: "data.txt", Content: strings.NewReader("0123456789")}
+				})
+			},
+			header:       http.Header{"Range": {"bytes=2-5"}},
+			responseCode: http.StatusPartialContent,
+			responseBody: "2345",
+			checkHeader: func(h http.Header) {
+				require.Equal(t, "bytes 2-5/10", h.Get("Content-Range"))
+			},
+		},
+		{
+			name: "Range not satisfiable",
+			api: func(r smartapi.Router) {
+				r.Get("/test", func() smartapi.File {
+					return smartapi.File{Name: "data.txt", Content: strings.NewReader("0123456789")}
+				})
+			},
+			header:       http.Header{"Range": {"bytes=20-30"}},
+			responseCode: http.StatusRequestedRangeNotSatisfiable,
+			responseBody: "invalid range: failed to overlap\n",
+		},
+		{
+			name: "Not modified since",
+			api: func(r smartapi.Router) {
+				r.Get("/test", func() smartapi.File {
+					return smartapi.File{Name: "data.txt", ModTime: modTime, Content: strings.NewReader("0123456789")}
+				})
+			},
+			header:       http.Header{"If-Modified-Since": {"Sat, 14 Mar 2020 12:00:00 GMT"}},
+			responseCode: http.StatusNotModified,
+		},
+		{
+			name: "ETag",
+			api: func(r smartapi.Router) {
+				r.Get("/test", func() smartapi.File {
+					return smartapi.File{Name: "data.txt", ETag: `"v1"`, Content: strings.NewReader("0123456789")}
+				})
+			},
+			header:       http.Header{"If-None-Match": {`"v1"`}},
+			responseCode: http.StatusNotModified,
+			checkHeader: func(h http.Header) {
+				require.Equal(t, `"v1"`, h.Get("ETag"))
+			},
+		},
+		{
+			name: "Head",
+			api: func(r smartapi.Router) {
+				r.Head("/test", func() smartapi.File {
+					return smartapi.File{Name: "data.txt", Content: strings.NewReader("0123456789")}
+				})
+			},
+			method:       "HEAD",
+			responseCode: http.StatusOK,
+			checkHeader: func(h http.Header) {
+				require.Equal(t, "10", h.Get("Content-Length"))
+			},
+		},
+		{
+			name: "Nil file",
+			api: func(r smartapi.Router) {
+				r.Get("/test", func() (*smartapi.File, error) {
+					return nil, nil
+				})
+			},
+			responseCode: http.StatusNoContent,
+		},
+		{
+			name: "Error",
+			api: func(r smartapi.Router) {
+				r.Get("/test", func() (*smartapi.File, error) {
+					return nil, smartapi.Error(http.StatusNotFound, "no such file", "file not found")
+				})
+			},
+			responseCode: http.StatusNotFound,
+			responseBody: `{"status":404,"reason":"file not found"}` + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := smartapi.NewRouterLogger(nil)
+			tt.api(r)
+
+			method := tt.method
+			if len(method) == 0 {
+				method = "GET"
+			}
+			rr := httptest.NewRecorder()
+			req, err := http.NewRequest(method, "/test", nil)
+			require.NoError(t, err)
+			for k, v := range tt.header {
+				req.Header[k] = v
+			}
+			r.MustHandler().ServeHTTP(rr, req)
+
+			require.Equal(t, tt.responseCode, rr.Code)
+			require.Equal(t, tt.responseBody, rr.Body.String())
+			if tt.checkHeader != nil {
+				tt.checkHeader(rr.Header())
+			}
+		})
+	}
+
+	require.True(t, content.closed)
+}
+
+func TestFileErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		api    func(r smartapi.Router)
+		expect error
+	}{
+		{
+			name: "Writes response",
+			api: func(r smartapi.Router) {
+				r.Get("/test", func(http.ResponseWriter) smartapi.File { return smartapi.File{} }, smartapi.ResponseWriter())
+			},
+			expect: errors.New("endpoint /test: cannot write response and return response"),
+		},
+		{
+			name: "Status",
+			api: func(r smartapi.Router) {
+				r.Get("/test", func() (smartapi.File, int, error) { return smartapi.File{}, 0, nil })
+			},
+			expect: errors.New("endpoint /test: unsupported return type"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := smartapi.NewRouterLogger(nil)
+			tt.api(r)
+			_, err := r.Handler()
+			require.Equal(t, tt.expect, err)
+		})
+	}
+}
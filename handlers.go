@@ -1,8 +1,6 @@
 package smartapi
 
 import (
-	"context"
-	"encoding/json"
 	"errors"
 	"net/http"
 	"reflect"
@@ -13,9 +11,14 @@ type endpointHandler interface {
 }
 
 func getCallAttributes(w http.ResponseWriter, r *http.Request, endpoint endpointData) ([]reflect.Value, error) {
+	if endpoint.multipart {
+		if err := parseMultipartForm(r); err != nil {
+			return nil, err
+		}
+	}
 	if endpoint.query {
 		if err := r.ParseForm(); err != nil {
-			return nil, WrapError(http.StatusBadRequest, err, "could not parse form")
+			return nil, bodyError(r, err, "could not parse form")
 		}
 	}
 
@@ -27,31 +30,31 @@ func getCallAttributes(w http.ResponseWriter, r *http.Request, endpoint endpoint
 		}
 		result = append(result, value)
 	}
+	if buffered, ok := w.(*bufferedResponseWriter); ok {
+		buffered.snapshot()
+	}
 	return result, nil
 }
 
-func handleErrorValue(ctx context.Context, w http.ResponseWriter, logger Logger, errorValue reflect.Value) {
+func handleErrorValue(w http.ResponseWriter, r *http.Request, logger Logger, errorValue reflect.Value) {
 	err, ok := errorValue.Interface().(error)
 	if !ok {
-		w.WriteHeader(http.StatusInternalServerError)
-		_ = json.NewEncoder(w).Encode(errorResponse{
-			Status: http.StatusInternalServerError,
-			Reason: "invalid API construction",
-		})
+		apiErr := Error(http.StatusInternalServerError, "invalid API construction", "invalid API construction")
+		writeError(w, r, apiErr, apiErr)
 		return
 	}
-	handleError(ctx, w, logger, err)
+	handleError(w, r, logger, err)
 }
 
-func handleError(ctx context.Context, w http.ResponseWriter, logger Logger, err error) {
-	var apiErr ApiError
-	if errors.As(err, &apiErr) {
+func handleError(w http.ResponseWriter, r *http.Request, logger Logger, err error) {
+	apiErr, ok := asApiError(r, err)
+	if ok {
 		if logger != nil {
-			logger.LogApiError(ctx, apiErr)
+			logger.LogApiError(r.Context(), apiErr)
 		}
 	} else {
 		if logger != nil {
-			logger.LogError(ctx, err)
+			logger.LogError(r.Context(), err)
 		}
 		apiErr = statusError{
 			errCode: http.StatusInternalServerError,
@@ -59,12 +62,68 @@ func handleError(ctx context.Context, w http.ResponseWriter, logger Logger, err
 			reason:  "unknown",
 		}
 	}
+	writeError(w, r, apiErr, err)
+}
+
+// asApiError returns the API error wrapped by err or converts err using router's error mappings
+func asApiError(r *http.Request, err error) (ApiError, bool) {
+	var apiErr ApiError
+	if errors.As(err, &apiErr) {
+		return apiErr, true
+	}
+	if apiErr, ok := mapError(configOf(r).errorMappings, err); ok {
+		return apiErr, true
+	}
+	if bodyTooLarge(r) {
+		return bodyTooLargeError(r), true
+	}
+	return nil, false
+}
 
-	w.WriteHeader(apiErr.Status())
-	_ = json.NewEncoder(w).Encode(errorResponse{
+// writeError writes an error response in the format selected by endpoint's settings
+func writeError(w http.ResponseWriter, r *http.Request, apiErr ApiError, err error) {
+	if buffered, ok := w.(*bufferedResponseWriter); ok {
+		buffered.reset()
+	}
+	if configOf(r).problemDetails {
+		writeProblem(w, r, problemOf(apiErr, err))
+		return
+	}
+
+	response := errorResponse{
 		Status: apiErr.Status(),
 		Reason: apiErr.Reason(),
-	})
+	}
+	var validationErr ValidationError
+	if errors.As(err, &validationErr) {
+		response.Errors = validationErr.Fields
+	}
+	var detailedErr *DetailedError
+	if errors.As(err, &detailedErr) {
+		for k, v := range detailedErr.Extensions {
+			if fields, ok := v.([]FieldError); ok && k == "errors" {
+				response.Errors = fields
+				continue
+			}
+			if response.Details == nil {
+				response.Details = map[string]interface{}{}
+			}
+			response.Details[k] = v
+		}
+	}
+	writeErrorResponse(w, r, response)
+}
+
+func writeErrorResponse(w http.ResponseWriter, r *http.Request, response errorResponse) {
+	contentType, codec := errorCodec(r)
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(response.Status)
+	_ = codec.Encode(w, response)
+}
+
+func encodeResponse(w http.ResponseWriter, contentType string, codec Codec, value interface{}) error {
+	w.Header().Set("Content-Type", contentType)
+	return codec.Encode(w, value)
 }
 
 type noResponseHandler struct {
@@ -74,7 +133,7 @@ type noResponseHandler struct {
 func (e noResponseHandler) handleRequest(w http.ResponseWriter, r *http.Request, logger Logger, endpoint endpointData) {
 	attribs, err := getCallAttributes(w, r, endpoint)
 	if err != nil {
-		handleError(r.Context(), w, logger, err)
+		handleError(w, r, logger, err)
 		return
 	}
 	value := reflect.ValueOf(e.handlerFunc)
@@ -89,7 +148,7 @@ type errorOnlyHandler struct {
 func (e errorOnlyHandler) handleRequest(w http.ResponseWriter, r *http.Request, logger Logger, endpoint endpointData) {
 	attribs, err := getCallAttributes(w, r, endpoint)
 	if err != nil {
-		handleError(r.Context(), w, logger, err)
+		handleError(w, r, logger, err)
 		return
 	}
 	value := reflect.ValueOf(e.handlerFunc)
@@ -98,7 +157,7 @@ func (e errorOnlyHandler) handleRequest(w http.ResponseWriter, r *http.Request,
 	errorValue := result[0]
 
 	if !errorValue.IsNil() {
-		handleErrorValue(r.Context(), w, logger, errorValue)
+		handleErrorValue(w, r, logger, errorValue)
 		return
 	}
 
@@ -110,9 +169,14 @@ type ptrErrorHandler struct {
 }
 
 func (e ptrErrorHandler) handleRequest(w http.ResponseWriter, r *http.Request, logger Logger, endpoint endpointData) {
+	contentType, codec, err := responseCodec(r)
+	if err != nil {
+		handleError(w, r, logger, err)
+		return
+	}
 	attribs, err := getCallAttributes(w, r, endpoint)
 	if err != nil {
-		handleError(r.Context(), w, logger, err)
+		handleError(w, r, logger, err)
 		return
 	}
 	value := reflect.ValueOf(e.handlerFunc)
@@ -122,17 +186,20 @@ func (e ptrErrorHandler) handleRequest(w http.ResponseWriter, r *http.Request, l
 	errorValue := result[1]
 
 	if !errorValue.IsNil() {
-		handleErrorValue(r.Context(), w, logger, errorValue)
+		handleErrorValue(w, r, logger, errorValue)
 		return
 	}
 
 	if responseValue.IsNil() {
-		w.WriteHeader(http.StatusNoContent)
-		return
+		var ok bool
+		if responseValue, ok = nilResponse(r, responseValue); !ok {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
 	}
 
-	if err := json.NewEncoder(w).Encode(responseValue.Interface()); err != nil {
-		handleError(r.Context(), w, logger, WrapError(http.StatusInternalServerError, err, "cannot encode response"))
+	if err := encodeResponse(w, contentType, codec, responseValue.Interface()); err != nil {
+		handleError(w, r, logger, WrapError(http.StatusInternalServerError, err, "cannot encode response"))
 		return
 	}
 }
@@ -142,9 +209,14 @@ type ptrHandler struct {
 }
 
 func (e ptrHandler) handleRequest(w http.ResponseWriter, r *http.Request, logger Logger, endpoint endpointData) {
+	contentType, codec, err := responseCodec(r)
+	if err != nil {
+		handleError(w, r, logger, err)
+		return
+	}
 	attribs, err := getCallAttributes(w, r, endpoint)
 	if err != nil {
-		handleError(r.Context(), w, logger, err)
+		handleError(w, r, logger, err)
 		return
 	}
 	value := reflect.ValueOf(e.handlerFunc)
@@ -153,12 +225,15 @@ func (e ptrHandler) handleRequest(w http.ResponseWriter, r *http.Request, logger
 	responseValue := result[0]
 
 	if responseValue.IsNil() {
-		w.WriteHeader(http.StatusNoContent)
-		return
+		var ok bool
+		if responseValue, ok = nilResponse(r, responseValue); !ok {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
 	}
 
-	if err := json.NewEncoder(w).Encode(responseValue.Interface()); err != nil {
-		handleError(r.Context(), w, logger, WrapError(http.StatusInternalServerError, err, "cannot encode response"))
+	if err := encodeResponse(w, contentType, codec, responseValue.Interface()); err != nil {
+		handleError(w, r, logger, WrapError(http.StatusInternalServerError, err, "cannot encode response"))
 		return
 	}
 }
@@ -168,9 +243,14 @@ type structErrorHandler struct {
 }
 
 func (s structErrorHandler) handleRequest(w http.ResponseWriter, r *http.Request, logger Logger, endpoint endpointData) {
+	contentType, codec, err := responseCodec(r)
+	if err != nil {
+		handleError(w, r, logger, err)
+		return
+	}
 	attribs, err := getCallAttributes(w, r, endpoint)
 	if err != nil {
-		handleError(r.Context(), w, logger, err)
+		handleError(w, r, logger, err)
 		return
 	}
 	value := reflect.ValueOf(s.handlerFunc)
@@ -180,12 +260,12 @@ func (s structErrorHandler) handleRequest(w http.ResponseWriter, r *http.Request
 	errorValue := result[1]
 
 	if !errorValue.IsNil() {
-		handleErrorValue(r.Context(), w, logger, errorValue)
+		handleErrorValue(w, r, logger, errorValue)
 		return
 	}
 
-	if err := json.NewEncoder(w).Encode(responseValue.Interface()); err != nil {
-		handleError(r.Context(), w, logger, WrapError(http.StatusInternalServerError, err, "cannot encode response"))
+	if err := encodeResponse(w, contentType, codec, responseValue.Interface()); err != nil {
+		handleError(w, r, logger, WrapError(http.StatusInternalServerError, err, "cannot encode response"))
 		return
 	}
 }
@@ -195,9 +275,14 @@ type structHandler struct {
 }
 
 func (s structHandler) handleRequest(w http.ResponseWriter, r *http.Request, logger Logger, endpoint endpointData) {
+	contentType, codec, err := responseCodec(r)
+	if err != nil {
+		handleError(w, r, logger, err)
+		return
+	}
 	attribs, err := getCallAttributes(w, r, endpoint)
 	if err != nil {
-		handleError(r.Context(), w, logger, err)
+		handleError(w, r, logger, err)
 		return
 	}
 	value := reflect.ValueOf(s.handlerFunc)
@@ -205,8 +290,8 @@ func (s structHandler) handleRequest(w http.ResponseWriter, r *http.Request, log
 
 	responseValue := result[0]
 
-	if err := json.NewEncoder(w).Encode(responseValue.Interface()); err != nil {
-		handleError(r.Context(), w, logger, WrapError(http.StatusInternalServerError, err, "cannot encode response"))
+	if err := encodeResponse(w, contentType, codec, responseValue.Interface()); err != nil {
+		handleError(w, r, logger, WrapError(http.StatusInternalServerError, err, "cannot encode response"))
 		return
 	}
 }
@@ -218,7 +303,7 @@ type stringErrorHandler struct {
 func (s stringErrorHandler) handleRequest(w http.ResponseWriter, r *http.Request, logger Logger, endpoint endpointData) {
 	attribs, err := getCallAttributes(w, r, endpoint)
 	if err != nil {
-		handleError(r.Context(), w, logger, err)
+		handleError(w, r, logger, err)
 		return
 	}
 	value := reflect.ValueOf(s.handlerFunc)
@@ -228,7 +313,7 @@ func (s stringErrorHandler) handleRequest(w http.ResponseWriter, r *http.Request
 	errorValue := result[1]
 
 	if !errorValue.IsNil() {
-		handleErrorValue(r.Context(), w, logger, errorValue)
+		handleErrorValue(w, r, logger, errorValue)
 		return
 	}
 
@@ -251,7 +336,7 @@ type stringHandler struct {
 func (s stringHandler) handleRequest(w http.ResponseWriter, r *http.Request, logger Logger, endpoint endpointData) {
 	attribs, err := getCallAttributes(w, r, endpoint)
 	if err != nil {
-		handleError(r.Context(), w, logger, err)
+		handleError(w, r, logger, err)
 		return
 	}
 	value := reflect.ValueOf(s.handlerFunc)
@@ -278,7 +363,7 @@ type byteSliceErrorHandler struct {
 func (b byteSliceErrorHandler) handleRequest(w http.ResponseWriter, r *http.Request, logger Logger, endpoint endpointData) {
 	attribs, err := getCallAttributes(w, r, endpoint)
 	if err != nil {
-		handleError(r.Context(), w, logger, err)
+		handleError(w, r, logger, err)
 		return
 	}
 	value := reflect.ValueOf(b.handlerFunc)
@@ -288,7 +373,7 @@ func (b byteSliceErrorHandler) handleRequest(w http.ResponseWriter, r *http.Requ
 	errorValue := result[1]
 
 	if !errorValue.IsNil() {
-		handleErrorValue(r.Context(), w, logger, errorValue)
+		handleErrorValue(w, r, logger, errorValue)
 		return
 	}
 
@@ -311,7 +396,7 @@ type byteSliceHandler struct {
 func (b byteSliceHandler) handleRequest(w http.ResponseWriter, r *http.Request, logger Logger, endpoint endpointData) {
 	attribs, err := getCallAttributes(w, r, endpoint)
 	if err != nil {
-		handleError(r.Context(), w, logger, err)
+		handleError(w, r, logger, err)
 		return
 	}
 	value := reflect.ValueOf(b.handlerFunc)
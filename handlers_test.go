@@ -1,7 +1,6 @@
 package smartapi
 
 import (
-	"context"
 	"errors"
 	"net/http"
 	"net/http/httptest"
@@ -47,7 +46,7 @@ func Test_handleErrorValue(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			rr := httptest.NewRecorder()
-			handleErrorValue(context.Background(), rr, tt.args.logger, tt.args.errorValue)
+			handleErrorValue(rr, &http.Request{}, tt.args.logger, tt.args.errorValue)
 			require.Equal(t, tt.responseCode, rr.Code)
 			require.Equal(t, tt.responseBody, rr.Body.String())
 		})
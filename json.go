@@ -0,0 +1,144 @@
+package smartapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// jsonOptions holds settings of decoding json bodies
+type jsonOptions struct {
+	disallowUnknownFields bool
+	useNumber             bool
+	rejectTrailingData    bool
+	allowEmptyBody        bool
+	detailedErrors        bool
+}
+
+// DisallowUnknownFields makes json bodies with fields not present in the structure to fail with 400 BAD REQUEST
+func DisallowUnknownFields() EndpointParam {
+	return configOption(func(c *endpointConfig) {
+		c.json.disallowUnknownFields = true
+	})
+}
+
+// UseNumber makes numbers of json bodies decoded into interface{} to be json.Number instead of float64
+func UseNumber() EndpointParam {
+	return configOption(func(c *endpointConfig) {
+		c.json.useNumber = true
+	})
+}
+
+// RejectTrailingData makes json bodies with any data after the json value to fail with 400 BAD REQUEST
+func RejectTrailingData() EndpointParam {
+	return configOption(func(c *endpointConfig) {
+		c.json.rejectTrailingData = true
+	})
+}
+
+// AllowEmptyBody makes an empty json body to be decoded as a zero value.
+// By default requests with an empty body fail with 400 BAD REQUEST.
+func AllowEmptyBody() EndpointParam {
+	return configOption(func(c *endpointConfig) {
+		c.json.allowEmptyBody = true
+	})
+}
+
+// DetailedJSONErrors makes errors of malformed json bodies to report the byte offset and the field of the problem
+func DetailedJSONErrors() EndpointParam {
+	return configOption(func(c *endpointConfig) {
+		c.json.detailedErrors = true
+	})
+}
+
+// StrictJSON disallows unknown fields and trailing data of json requests
+func StrictJSON() EndpointParam {
+	return configOption(func(c *endpointConfig) {
+		c.json.disallowUnknownFields = true
+		c.json.rejectTrailingData = true
+	})
+}
+
+// countingReader counts bytes read from the underlying reader
+type countingReader struct {
+	reader io.Reader
+	count  int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.reader.Read(p)
+	c.count += int64(n)
+	return n, err
+}
+
+// decodeJSON decodes request's json body according to endpoint's json options
+func decodeJSON(r *http.Request, v interface{}) error {
+	opts := configOf(r).json
+	body := &countingReader{reader: r.Body}
+	decoder := json.NewDecoder(body)
+	if opts.disallowUnknownFields {
+		decoder.DisallowUnknownFields()
+	}
+	if opts.useNumber {
+		decoder.UseNumber()
+	}
+
+	if err := decoder.Decode(v); err != nil {
+		if err == io.EOF && opts.allowEmptyBody {
+			return nil
+		}
+		return jsonError(opts, decoder, body.count, err)
+	}
+
+	if opts.rejectTrailingData {
+		offset := decoder.InputOffset()
+		if _, err := decoder.Token(); err != io.EOF {
+			return jsonDecodeError(opts, errors.New("unexpected data after json value"), offset, "")
+		}
+	}
+	return nil
+}
+
+const unknownFieldPrefix = "json: unknown field "
+
+// jsonError converts a decoding error into an API error, other errors are returned as they are
+func jsonError(opts jsonOptions, decoder *json.Decoder, read int64, err error) error {
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return jsonDecodeError(opts, err, syntaxErr.Offset, "")
+	}
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return jsonDecodeError(opts, err, typeErr.Offset, typeErr.Field)
+	}
+	if err == io.ErrUnexpectedEOF {
+		return jsonDecodeError(opts, err, read, "")
+	}
+	if strings.HasPrefix(err.Error(), unknownFieldPrefix) {
+		field, unquoteErr := strconv.Unquote(strings.TrimPrefix(err.Error(), unknownFieldPrefix))
+		if unquoteErr != nil {
+			field = ""
+		}
+		return jsonDecodeError(opts, err, decoder.InputOffset(), field)
+	}
+	return err
+}
+
+// jsonDecodeError results in 400 BAD REQUEST, the offset and the field are attached only with detailed json errors
+func jsonDecodeError(opts jsonOptions, err error, offset int64, field string) error {
+	apiErr := WrapError(http.StatusBadRequest, err, "cannot unmarshal request")
+	if !opts.detailedErrors {
+		return apiErr
+	}
+	result := WithDetail(apiErr, "offset", offset)
+	result.Detail = fmt.Sprintf("invalid json at offset %d", offset)
+	if len(field) != 0 {
+		result.With("field", field)
+		result.Detail = fmt.Sprintf("invalid json field %s at offset %d", field, offset)
+	}
+	return result
+}
@@ -0,0 +1,166 @@
+package smartapi_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mmbednarek/smartapi"
+	"github.com/stretchr/testify/require"
+)
+
+type jsonTestUser struct {
+	Name    string `json:"name"`
+	Age     int    `json:"age"`
+	Address struct {
+		City string `json:"city"`
+	} `json:"address"`
+}
+
+func TestJSONOptions(t *testing.T) {
+	tests := []struct {
+		name         string
+		api          func(r smartapi.Router)
+		body         string
+		responseCode int
+		responseBody string
+	}{
+		{
+			name: "Unknown fields are ignored",
+			api: func(r smartapi.Router) {
+				r.Post("/test", func(u *jsonTestUser) {
+					require.Equal(t, "John", u.Name)
+				}, smartapi.JSONBody(jsonTestUser{}))
+			},
+			body:         `{"name":"John","nme":"Smith"}`,
+			responseCode: http.StatusNoContent,
+		},
+		{
+			name: "DisallowUnknownFields",
+			api: func(r smartapi.Router) {
+				r.Post("/test", func(*jsonTestUser) {}, smartapi.JSONBody(jsonTestUser{}), smartapi.DisallowUnknownFields())
+			},
+			body:         `{"name":"John", "nme":"Smith"}`,
+			responseCode: http.StatusBadRequest,
+			responseBody: `{"status":400,"reason":"cannot unmarshal request"}` + "\n",
+		},
+		{
+			name: "Detailed unknown field",
+			api: func(r smartapi.Router) {
+				r.Post("/test", func(*jsonTestUser) {}, smartapi.JSONBody(jsonTestUser{}), smartapi.DisallowUnknownFields(), smartapi.DetailedJSONErrors())
+			},
+			body:         `{"name":"John", "nme":"Smith"}`,
+			responseCode: http.StatusBadRequest,
+			responseBody: `{"status":400,"reason":"cannot unmarshal request","details":{"field":"nme","offset":30}}` + "\n",
+		},
+		{
+			name: "Invalid type",
+			api: func(r smartapi.Router) {
+				r.Post("/test", func(jsonTestUser) {}, smartapi.JSONBodyDirect(jsonTestUser{}), smartapi.DetailedJSONErrors())
+			},
+			body:         `{"name":"John","address":{"city":12}}`,
+			responseCode: http.StatusBadRequest,
+			responseBody: `{"status":400,"reason":"cannot unmarshal request","details":{"field":"address.city","offset":35}}` + "\n",
+		},
+		{
+			name: "Syntax error",
+			api: func(r smartapi.Router) {
+				r.Post("/test", func(jsonTestUser) {}, smartapi.JSONBodyDirect(jsonTestUser{}), smartapi.DetailedJSONErrors())
+			},
+			body:         `{"name":"John",}`,
+			responseCode: http.StatusBadRequest,
+			responseBody: `{"status":400,"reason":"cannot unmarshal request","details":{"offset":16}}` + "\n",
+		},
+		{
+			name: "Unexpected end",
+			api: func(r smartapi.Router) {
+				r.Post("/test", func(jsonTestUser) {}, smartapi.JSONBodyDirect(jsonTestUser{}), smartapi.DetailedJSONErrors())
+			},
+			body:         `{"name":"Jo`,
+			responseCode: http.StatusBadRequest,
+			responseBody: `{"status":400,"reason":"cannot unmarshal request","details":{"offset":11}}` + "\n",
+		},
+		{
+			name: "Trailing data is ignored",
+			api: func(r smartapi.Router) {
+				r.Post("/test", func(*jsonTestUser) {}, smartapi.JSONBody(jsonTestUser{}))
+			},
+			body:         `{"name":"John"} garbage`,
+			responseCode: http.StatusNoContent,
+		},
+		{
+			name: "RejectTrailingData",
+			api: func(r smartapi.Router) {
+				r.Post("/test", func(*jsonTestUser) {}, smartapi.JSONBody(jsonTestUser{}), smartapi.RejectTrailingData(), smartapi.DetailedJSONErrors())
+			},
+			body:         `{"name":"John"} {"name":"Bob"}`,
+			responseCode: http.StatusBadRequest,
+			responseBody: `{"status":400,"reason":"cannot unmarshal request","details":{"offset":15}}` + "\n",
+		},
+		{
+			name: "Empty body",
+			api: func(r smartapi.Router) {
+				r.Post("/test", func(*jsonTestUser) {}, smartapi.JSONBody(jsonTestUser{}))
+			},
+			body:         " \n",
+			responseCode: http.StatusBadRequest,
+			responseBody: `{"status":400,"reason":"cannot unmarshal request"}` + "\n",
+		},
+		{
+			name: "AllowEmptyBody",
+			api: func(r smartapi.Router) {
+				r.Post("/test", func(u *jsonTestUser) {
+					require.Equal(t, &jsonTestUser{}, u)
+				}, smartapi.JSONBody(jsonTestUser{}), smartapi.AllowEmptyBody())
+			},
+			responseCode: http.StatusNoContent,
+		},
+		{
+			name: "UseNumber",
+			api: func(r smartapi.Router) {
+				r.Post("/test", func(v *map[string]interface{}) {
+					require.Equal(t, json.Number("12345678901234567890"), (*v)["id"])
+				}, smartapi.JSONBody(map[string]interface{}{}), smartapi.UseNumber())
+			},
+			body:         `{"id":12345678901234567890}`,
+			responseCode: http.StatusNoContent,
+		},
+		{
+			name: "StrictJSON router default",
+			api: func(r smartapi.Router) {
+				r.Defaults(smartapi.StrictJSON(), smartapi.DetailedJSONErrors())
+				r.Post("/test", func(*jsonTestUser) {}, smartapi.Body(jsonTestUser{}))
+			},
+			body:         `{"name":"John","age":34,"email":"john@example.com"}`,
+			responseCode: http.StatusBadRequest,
+			responseBody: `{"status":400,"reason":"cannot unmarshal request","details":{"field":"email","offset":51}}` + "\n",
+		},
+		{
+			name: "Problem details",
+			api: func(r smartapi.Router) {
+				r.Post("/test", func(*jsonTestUser) {}, smartapi.JSONBody(jsonTestUser{}), smartapi.ProblemDetails(), smartapi.DetailedJSONErrors())
+			},
+			body:         `{"name":"John","age":"34"}`,
+			responseCode: http.StatusBadRequest,
+			responseBody: `{"detail":"invalid json field age at offset 25","field":"age","offset":25,` +
+				`"status":400,"title":"cannot unmarshal request","type":"about:blank"}` + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := smartapi.NewRouterLogger(nil)
+			tt.api(r)
+
+			rr := httptest.NewRecorder()
+			req, err := http.NewRequest("POST", "/test", strings.NewReader(tt.body))
+			require.NoError(t, err)
+			r.MustHandler().ServeHTTP(rr, req)
+
+			require.Equal(t, tt.responseCode, rr.Code)
+			require.Equal(t, tt.responseBody, rr.Body.String())
+		})
+	}
+}
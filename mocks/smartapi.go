@@ -58,6 +58,41 @@ func (mr *MockLoggerMockRecorder) LogError(ctx, err interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LogError", reflect.TypeOf((*MockLogger)(nil).LogError), ctx, err)
 }
 
+// MockPanicLogger is a mock of PanicLogger interface
+type MockPanicLogger struct {
+	ctrl     *gomock.Controller
+	recorder *MockPanicLoggerMockRecorder
+}
+
+// MockPanicLoggerMockRecorder is the mock recorder for MockPanicLogger
+type MockPanicLoggerMockRecorder struct {
+	mock *MockPanicLogger
+}
+
+// NewMockPanicLogger creates a new mock instance
+func NewMockPanicLogger(ctrl *gomock.Controller) *MockPanicLogger {
+	mock := &MockPanicLogger{ctrl: ctrl}
+	mock.recorder = &MockPanicLoggerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockPanicLogger) EXPECT() *MockPanicLoggerMockRecorder {
+	return m.recorder
+}
+
+// LogPanic mocks base method
+func (m *MockPanicLogger) LogPanic(ctx context.Context, value interface{}, stack []byte) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "LogPanic", ctx, value, stack)
+}
+
+// LogPanic indicates an expected call of LogPanic
+func (mr *MockPanicLoggerMockRecorder) LogPanic(ctx, value, stack interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LogPanic", reflect.TypeOf((*MockPanicLogger)(nil).LogPanic), ctx, value, stack)
+}
+
 // MockAPI is a mock of API interface
 type MockAPI struct {
 	ctrl     *gomock.Controller
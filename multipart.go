@@ -0,0 +1,295 @@
+package smartapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"reflect"
+)
+
+const defaultMultipartMemory = 32 << 20
+
+var multipartFileType = reflect.TypeOf((*multipart.File)(nil)).Elem()
+var fileHeaderType = reflect.TypeOf(&multipart.FileHeader{})
+var fileHeadersType = reflect.TypeOf([]*multipart.FileHeader(nil))
+var multipartReaderType = reflect.TypeOf(&multipart.Reader{})
+
+// multipartLimits holds limits of a parsed multipart form, zero values mean no limit
+type multipartLimits struct {
+	memory      int64
+	maxFileSize int64
+	maxParts    int
+}
+
+// MultipartMemory sets the number of bytes of a multipart form kept in memory, the rest of files is stored in temporary files.
+// The default is 32 MB.
+func MultipartMemory(bytes int64) EndpointParam {
+	return configOption(func(c *endpointConfig) {
+		c.multipart.memory = bytes
+	})
+}
+
+// MaxFileSize makes requests with a multipart file larger than the limit to fail with 413 REQUEST ENTITY TOO LARGE
+func MaxFileSize(bytes int64) EndpointParam {
+	return configOption(func(c *endpointConfig) {
+		c.multipart.maxFileSize = bytes
+	})
+}
+
+// MaxParts makes requests with a multipart form of more parts than the limit to fail with 413 REQUEST ENTITY TOO LARGE
+func MaxParts(n int) EndpointParam {
+	return configOption(func(c *endpointConfig) {
+		c.multipart.maxParts = n
+	})
+}
+
+// bodyReaders counts arguments reading request's body. Arguments reading the multipart form share it and count as one.
+type bodyReaders struct {
+	count         int
+	multipartForm bool
+}
+
+func (b *bodyReaders) add(flags endpointOptions) {
+	if !flags.has(flagReadsRequestBody) {
+		return
+	}
+	if flags.has(flagReadsMultipartForm) {
+		if b.multipartForm {
+			return
+		}
+		b.multipartForm = true
+	}
+	b.count++
+}
+
+func multipartError(err error) error {
+	if errors.Is(err, http.ErrNotMultipart) {
+		return Error(http.StatusUnsupportedMediaType, err.Error(), "unsupported content type")
+	}
+	if errors.Is(err, multipart.ErrMessageTooLarge) {
+		return WrapError(http.StatusRequestEntityTooLarge, err, "multipart form too large")
+	}
+	return WrapError(http.StatusBadRequest, err, "invalid multipart form")
+}
+
+// parseMultipartForm parses request's multipart form respecting endpoint's limits.
+// Values of the form are added to request's Form and PostForm.
+func parseMultipartForm(r *http.Request) error {
+	if r.MultipartForm != nil {
+		return nil
+	}
+	if r.PostForm == nil {
+		if err := r.ParseForm(); err != nil {
+			return bodyError(r, err, "could not parse form")
+		}
+	}
+
+	source, err := r.MultipartReader()
+	if err != nil {
+		return multipartError(err)
+	}
+	limits := configOf(r).multipart
+	if limits.memory <= 0 {
+		limits.memory = defaultMultipartMemory
+	}
+
+	// Parts are copied through a pipe to check limits before they are stored
+	pr, pw := io.Pipe()
+	writer := multipart.NewWriter(pw)
+	done := make(chan error, 1)
+	go func() {
+		err := copyParts(writer, source, limits)
+		_ = pw.CloseWithError(err)
+		done <- err
+	}()
+
+	form, err := multipart.NewReader(pr, writer.Boundary()).ReadForm(limits.memory)
+	_ = pr.Close()
+	copyErr := <-done
+	var apiErr ApiError
+	if errors.As(copyErr, &apiErr) {
+		if form != nil {
+			_ = form.RemoveAll()
+		}
+		return apiErr
+	}
+	if bodyTooLarge(r) {
+		if form != nil {
+			_ = form.RemoveAll()
+		}
+		return bodyTooLargeError(r)
+	}
+	if err != nil {
+		return multipartError(err)
+	}
+	if copyErr != nil {
+		_ = form.RemoveAll()
+		return multipartError(copyErr)
+	}
+
+	for key, values := range form.Value {
+		r.Form[key] = append(r.Form[key], values...)
+		r.PostForm[key] = append(r.PostForm[key], values...)
+	}
+	r.MultipartForm = form
+	return nil
+}
+
+func copyParts(writer *multipart.Writer, source *multipart.Reader, limits multipartLimits) error {
+	for numParts := 0; ; numParts++ {
+		part, err := source.NextPart()
+		if err == io.EOF {
+			return writer.Close()
+		}
+		if err != nil {
+			return err
+		}
+		if limits.maxParts > 0 && numParts >= limits.maxParts {
+			msg := fmt.Sprintf("multipart form exceeds the limit of %d parts", limits.maxParts)
+			return Error(http.StatusRequestEntityTooLarge, msg, msg)
+		}
+
+		dst, err := writer.CreatePart(part.Header)
+		if err != nil {
+			return err
+		}
+		var src io.Reader = part
+		if len(part.FileName()) != 0 && limits.maxFileSize > 0 {
+			src = io.LimitReader(part, limits.maxFileSize+1)
+		}
+		written, err := io.Copy(dst, src)
+		if err != nil {
+			return err
+		}
+		if len(part.FileName()) != 0 && limits.maxFileSize > 0 && written > limits.maxFileSize {
+			msg := fmt.Sprintf("file %s exceeds the limit of %d bytes", part.FormName(), limits.maxFileSize)
+			return Error(http.StatusRequestEntityTooLarge, msg, msg)
+		}
+	}
+}
+
+type multipartCleanupKey struct{}
+
+// multipartCleanup holds files opened for the handler
+type multipartCleanup struct {
+	files []io.Closer
+}
+
+// withMultipartCleanup returns a function closing files opened for the handler and removing temporary files of the form
+func withMultipartCleanup(r *http.Request) (*http.Request, func()) {
+	cleanup := &multipartCleanup{}
+	r = r.WithContext(context.WithValue(r.Context(), multipartCleanupKey{}, cleanup))
+	return r, func() {
+		for _, f := range cleanup.files {
+			_ = f.Close()
+		}
+		if r.MultipartForm != nil {
+			_ = r.MultipartForm.RemoveAll()
+		}
+	}
+}
+
+func closeAfterHandler(r *http.Request, f io.Closer) {
+	if cleanup, ok := r.Context().Value(multipartCleanupKey{}).(*multipartCleanup); ok {
+		cleanup.files = append(cleanup.files, f)
+	}
+}
+
+type multipartFileArgument struct {
+	name string
+	open bool
+}
+
+func (multipartFileArgument) options() endpointOptions {
+	return flagArgument | flagReadsRequestBody | flagReadsMultipartForm
+}
+
+func (m multipartFileArgument) checkArg(arg reflect.Type) error {
+	if arg != multipartFileType && arg != fileHeaderType {
+		return errors.New("argument's type must be multipart.File or *multipart.FileHeader")
+	}
+	return nil
+}
+
+func (m multipartFileArgument) getValue(w http.ResponseWriter, r *http.Request) (reflect.Value, error) {
+	if err := parseMultipartForm(r); err != nil {
+		return reflect.Value{}, err
+	}
+	files := r.MultipartForm.File[m.name]
+	if len(files) == 0 {
+		return reflect.Value{}, missingParamError("file " + m.name)
+	}
+	if !m.open {
+		return reflect.ValueOf(files[0]), nil
+	}
+
+	file, err := files[0].Open()
+	if err != nil {
+		return reflect.Value{}, fmt.Errorf("cannot open file %s: %w", m.name, err)
+	}
+	closeAfterHandler(r, file)
+	return reflect.ValueOf(&file).Elem(), nil
+}
+
+// MultipartFile reads a file from request's multipart form and passes it as multipart.File or *multipart.FileHeader.
+// The file is closed after the handler returns. Request without the file fails with 400 BAD REQUEST.
+func MultipartFile(name string) EndpointParam {
+	return multipartFileArgument{name: name}
+}
+
+type multipartFilesArgument struct {
+	name string
+}
+
+func (multipartFilesArgument) options() endpointOptions {
+	return flagArgument | flagReadsRequestBody | flagReadsMultipartForm
+}
+
+func (multipartFilesArgument) checkArg(arg reflect.Type) error {
+	if arg != fileHeadersType {
+		return errors.New("argument's type must be []*multipart.FileHeader")
+	}
+	return nil
+}
+
+func (m multipartFilesArgument) getValue(w http.ResponseWriter, r *http.Request) (reflect.Value, error) {
+	if err := parseMultipartForm(r); err != nil {
+		return reflect.Value{}, err
+	}
+	return reflect.ValueOf(r.MultipartForm.File[m.name]), nil
+}
+
+// MultipartFiles reads all files of a name from request's multipart form and passes them as []*multipart.FileHeader
+func MultipartFiles(name string) EndpointParam {
+	return multipartFilesArgument{name: name}
+}
+
+type multipartReaderArgument struct{}
+
+func (multipartReaderArgument) options() endpointOptions {
+	return flagArgument | flagReadsRequestBody
+}
+
+func (multipartReaderArgument) checkArg(arg reflect.Type) error {
+	if arg != multipartReaderType {
+		return errors.New("argument's type must be *multipart.Reader")
+	}
+	return nil
+}
+
+func (multipartReaderArgument) getValue(w http.ResponseWriter, r *http.Request) (reflect.Value, error) {
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return reflect.Value{}, multipartError(err)
+	}
+	return reflect.ValueOf(reader), nil
+}
+
+// MultipartReader passes a *multipart.Reader streaming parts of request's multipart body.
+// Parts are not stored, so limits of the multipart form don't apply.
+func MultipartReader() EndpointParam {
+	return multipartReaderArgument{}
+}
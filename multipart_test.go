@@ -0,0 +1,276 @@
+package smartapi_test
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mmbednarek/smartapi"
+	"github.com/stretchr/testify/require"
+)
+
+type multipartTestPart struct {
+	name     string
+	fileName string
+	content  string
+}
+
+func multipartRequest(parts ...multipartTestPart) *http.Request {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for _, p := range parts {
+		var w io.Writer
+		if len(p.fileName) != 0 {
+			w, _ = writer.CreateFormFile(p.name, p.fileName)
+		} else {
+			w, _ = writer.CreateFormField(p.name)
+		}
+		_, _ = w.Write([]byte(p.content))
+	}
+	_ = writer.Close()
+
+	req, _ := http.NewRequest("POST", "/test", &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return req
+}
+
+func TestMultipart(t *testing.T) {
+	tests := []struct {
+		name         string
+		api          func(r smartapi.Router)
+		request      func() *http.Request
+		responseCode int
+		responseBody string
+	}{
+		{
+			name: "MultipartFile",
+			api: func(r smartapi.Router) {
+				r.Post("/test", func(file multipart.File, header *multipart.FileHeader, name string) {
+					content, err := ioutil.ReadAll(file)
+					require.NoError(t, err)
+					require.Equal(t, "avatar content", string(content))
+					require.Equal(t, "avatar.png", header.Filename)
+					require.Equal(t, "John", name)
+				},
+					smartapi.MultipartFile("avatar"),
+					smartapi.MultipartFile("avatar"),
+					smartapi.PostQueryParam("name"),
+				)
+			},
+			request: func() *http.Request {
+				return multipartRequest(
+					multipartTestPart{name: "name", content: "John"},
+					multipartTestPart{name: "avatar", fileName: "avatar.png", content: "avatar content"},
+				)
+			},
+			responseCode: http.StatusNoContent,
+		},
+		{
+			name: "MultipartFiles",
+			api: func(r smartapi.Router) {
+				r.Post("/test", func(files []*multipart.FileHeader) {
+					require.Len(t, files, 2)
+					require.Equal(t, "a.txt", files[0].Filename)
+					require.Equal(t, "b.txt", files[1].Filename)
+				},
+					smartapi.MultipartFiles("attachments"),
+				)
+			},
+			request: func() *http.Request {
+				return multipartRequest(
+					multipartTestPart{name: "attachments", fileName: "a.txt", content: "a"},
+					multipartTestPart{name: "attachments", fileName: "b.txt", content: "b"},
+				)
+			},
+			responseCode: http.StatusNoContent,
+		},
+		{
+			name: "Tags",
+			api: func(r smartapi.Router) {
+				type request struct {
+					Avatar      multipart.File          `smartapi:"form_file=avatar"`
+					Attachments []*multipart.FileHeader `smartapi:"form_file=attachments"`
+				}
+				r.Post("/test", func(rq *request) {
+					content, err := ioutil.ReadAll(rq.Avatar)
+					require.NoError(t, err)
+					require.Equal(t, "avatar content", string(content))
+					require.Len(t, rq.Attachments, 0)
+				},
+					smartapi.RequestStruct(request{}),
+				)
+			},
+			request: func() *http.Request {
+				return multipartRequest(multipartTestPart{name: "avatar", fileName: "avatar.png", content: "avatar content"})
+			},
+			responseCode: http.StatusNoContent,
+		},
+		{
+			name: "MultipartReader",
+			api: func(r smartapi.Router) {
+				r.Post("/test", func(reader *multipart.Reader) (string, error) {
+					var names []string
+					for {
+						part, err := reader.NextPart()
+						if err == io.EOF {
+							return strings.Join(names, ","), nil
+						}
+						if err != nil {
+							return "", err
+						}
+						names = append(names, part.FormName())
+					}
+				},
+					smartapi.MultipartReader(),
+				)
+			},
+			request: func() *http.Request {
+				return multipartRequest(
+					multipartTestPart{name: "a", content: "1"},
+					multipartTestPart{name: "b", fileName: "b.txt", content: "2"},
+				)
+			},
+			responseCode: http.StatusOK,
+			responseBody: "a,b",
+		},
+		{
+			name: "Missing file",
+			api: func(r smartapi.Router) {
+				r.Post("/test", func(*multipart.FileHeader) {}, smartapi.MultipartFile("avatar"))
+			},
+			request: func() *http.Request {
+				return multipartRequest(multipartTestPart{name: "name", content: "John"})
+			},
+			responseCode: http.StatusBadRequest,
+			responseBody: `{"status":400,"reason":"missing required file avatar"}` + "\n",
+		},
+		{
+			name: "Not multipart",
+			api: func(r smartapi.Router) {
+				r.Post("/test", func(*multipart.FileHeader) {}, smartapi.MultipartFile("avatar"))
+			},
+			request: func() *http.Request {
+				req, _ := http.NewRequest("POST", "/test", strings.NewReader("{}"))
+				req.Header.Set("Content-Type", "application/json")
+				return req
+			},
+			responseCode: http.StatusUnsupportedMediaType,
+			responseBody: `{"status":415,"reason":"unsupported content type"}` + "\n",
+		},
+		{
+			name: "MaxFileSize",
+			api: func(r smartapi.Router) {
+				r.Post("/test", func(*multipart.FileHeader) {}, smartapi.MultipartFile("avatar"), smartapi.MaxFileSize(4))
+			},
+			request: func() *http.Request {
+				return multipartRequest(multipartTestPart{name: "avatar", fileName: "avatar.png", content: "avatar content"})
+			},
+			responseCode: http.StatusRequestEntityTooLarge,
+			responseBody: `{"status":413,"reason":"file avatar exceeds the limit of 4 bytes"}` + "\n",
+		},
+		{
+			name: "MaxParts",
+			api: func(r smartapi.Router) {
+				r.Post("/test", func([]*multipart.FileHeader) {}, smartapi.MultipartFiles("files"), smartapi.MaxParts(2))
+			},
+			request: func() *http.Request {
+				return multipartRequest(
+					multipartTestPart{name: "files", fileName: "a.txt", content: "a"},
+					multipartTestPart{name: "files", fileName: "b.txt", content: "b"},
+					multipartTestPart{name: "files", fileName: "c.txt", content: "c"},
+				)
+			},
+			responseCode: http.StatusRequestEntityTooLarge,
+			responseBody: `{"status":413,"reason":"multipart form exceeds the limit of 2 parts"}` + "\n",
+		},
+		{
+			name: "MaxBodySize",
+			api: func(r smartapi.Router) {
+				r.Post("/test", func(*multipart.FileHeader) {}, smartapi.MultipartFile("avatar"), smartapi.MaxBodySize(64))
+			},
+			request: func() *http.Request {
+				return multipartRequest(multipartTestPart{name: "avatar", fileName: "avatar.png", content: strings.Repeat("a", 128)})
+			},
+			responseCode: http.StatusRequestEntityTooLarge,
+			responseBody: `{"status":413,"reason":"request body exceeds the limit of 64 bytes"}` + "\n",
+		},
+		{
+			name: "Files stored on disk",
+			api: func(r smartapi.Router) {
+				r.Post("/test", func(file multipart.File) {
+					content, err := ioutil.ReadAll(file)
+					require.NoError(t, err)
+					require.Equal(t, "avatar content", string(content))
+				},
+					smartapi.MultipartFile("avatar"),
+					smartapi.MultipartMemory(1),
+				)
+			},
+			request: func() *http.Request {
+				return multipartRequest(multipartTestPart{name: "avatar", fileName: "avatar.png", content: "avatar content"})
+			},
+			responseCode: http.StatusNoContent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := smartapi.NewRouterLogger(nil)
+			tt.api(r)
+
+			rr := httptest.NewRecorder()
+			r.MustHandler().ServeHTTP(rr, tt.request())
+
+			require.Equal(t, tt.responseCode, rr.Code)
+			require.Equal(t, tt.responseBody, rr.Body.String())
+		})
+	}
+}
+
+func TestMultipartErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		api  func(r smartapi.Router)
+		err  string
+	}{
+		{
+			name: "Invalid type",
+			api: func(r smartapi.Router) {
+				r.Post("/test", func([]byte) {}, smartapi.MultipartFile("avatar"))
+			},
+			err: "endpoint /test: (argument 0) argument's type must be multipart.File or *multipart.FileHeader",
+		},
+		{
+			name: "Reads body",
+			api: func(r smartapi.Router) {
+				r.Post("/test", func(*multipart.FileHeader, string) {}, smartapi.MultipartFile("avatar"), smartapi.StringBody())
+			},
+			err: "endpoint /test: only one argument can read request's body",
+		},
+		{
+			name: "Reader and form",
+			api: func(r smartapi.Router) {
+				type request struct {
+					Avatar *multipart.FileHeader `smartapi:"form_file=avatar"`
+					Reader *multipart.Reader     `smartapi:"multipart_reader"`
+				}
+				r.Post("/test", func(*request) {}, smartapi.RequestStruct(request{}))
+			},
+			err: "endpoint /test: (argument 0) only one struct field can read request's body",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := smartapi.NewRouterLogger(nil)
+			tt.api(r)
+			_, err := r.Handler()
+			require.EqualError(t, err, tt.err)
+		})
+	}
+}
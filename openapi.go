@@ -0,0 +1,400 @@
+package smartapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const openAPIVersion = "3.0.3"
+
+// OpenAPIDocument represents an OpenAPI 3 document
+type OpenAPIDocument struct {
+	OpenAPI    string                     `json:"openapi"`
+	Info       OpenAPIInfo                `json:"info"`
+	Paths      map[string]OpenAPIPathItem `json:"paths"`
+	Components *OpenAPIComponents         `json:"components,omitempty"`
+}
+
+// OpenAPIInfo contains metadata of an API
+type OpenAPIInfo struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// OpenAPIPathItem maps lowercase http methods into operations
+type OpenAPIPathItem map[string]*OpenAPIOperation
+
+// OpenAPIOperation describes a single endpoint
+type OpenAPIOperation struct {
+	Parameters  []OpenAPIParameter         `json:"parameters,omitempty"`
+	RequestBody *OpenAPIRequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]OpenAPIResponse `json:"responses"`
+}
+
+// OpenAPIParameter describes a path, query, header or cookie parameter
+type OpenAPIParameter struct {
+	Name     string         `json:"name"`
+	In       string         `json:"in"`
+	Required bool           `json:"required,omitempty"`
+	Schema   *OpenAPISchema `json:"schema,omitempty"`
+}
+
+// OpenAPIRequestBody describes a request body
+type OpenAPIRequestBody struct {
+	Required bool                        `json:"required,omitempty"`
+	Content  map[string]OpenAPIMediaType `json:"content"`
+}
+
+// OpenAPIMediaType describes a content of a body
+type OpenAPIMediaType struct {
+	Schema *OpenAPISchema `json:"schema,omitempty"`
+}
+
+// OpenAPIResponse describes a response
+type OpenAPIResponse struct {
+	Description string                      `json:"description"`
+	Content     map[string]OpenAPIMediaType `json:"content,omitempty"`
+}
+
+// OpenAPIComponents holds reusable schemas
+type OpenAPIComponents struct {
+	Schemas map[string]*OpenAPISchema `json:"schemas,omitempty"`
+}
+
+// OpenAPISchema describes a data type
+type OpenAPISchema struct {
+	Ref                  string                    `json:"$ref,omitempty"`
+	Type                 string                    `json:"type,omitempty"`
+	Format               string                    `json:"format,omitempty"`
+	Nullable             bool                      `json:"nullable,omitempty"`
+	Items                *OpenAPISchema            `json:"items,omitempty"`
+	Properties           map[string]*OpenAPISchema `json:"properties,omitempty"`
+	AdditionalProperties *OpenAPISchema            `json:"additionalProperties,omitempty"`
+}
+
+// OpenAPI generates an OpenAPI document describing all endpoints registered in the router
+func (r *router) OpenAPI(info OpenAPIInfo) *OpenAPIDocument {
+	schemas := newSchemaRegistry()
+	doc := &OpenAPIDocument{
+		OpenAPI: openAPIVersion,
+		Info:    info,
+		Paths:   map[string]OpenAPIPathItem{},
+	}
+
+	for _, e := range r.state.endpoints {
+		path, pathParams := openAPIPath(e.pattern)
+		item, ok := doc.Paths[path]
+		if !ok {
+			item = OpenAPIPathItem{}
+			doc.Paths[path] = item
+		}
+		item[strings.ToLower(e.method.String())] = openAPIOperation(e, pathParams, schemas)
+	}
+
+	if len(schemas.schemas) > 0 {
+		doc.Components = &OpenAPIComponents{Schemas: schemas.schemas}
+	}
+	return doc
+}
+
+// OpenAPIHandler returns an http.Handler serving the OpenAPI document in a json format
+func (r *router) OpenAPIHandler(info OpenAPIInfo) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, rq *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(r.OpenAPI(info))
+	})
+}
+
+func openAPIOperation(e endpointInfo, pathParams []string, schemas *schemaRegistry) *OpenAPIOperation {
+	op := &OpenAPIOperation{
+		Responses: openAPIResponses(e, schemas),
+	}
+
+	declared := map[string]bool{}
+	form := &OpenAPISchema{Type: "object", Properties: map[string]*OpenAPISchema{}}
+	formContentType := "application/x-www-form-urlencoded"
+	for _, a := range flattenArguments(describeArguments(e.handler, e.arguments)) {
+		switch a.location {
+		case locationPath, locationQuery, locationHeader, locationCookie:
+			if a.location == locationPath {
+				declared[a.name] = true
+			}
+			op.Parameters = append(op.Parameters, OpenAPIParameter{
+				Name:     a.name,
+				In:       openAPILocations[a.location],
+				Required: a.required,
+				Schema:   schemas.schemaOf(a.typ),
+			})
+		case locationPostForm:
+			form.Properties[a.name] = schemas.schemaOf(a.typ)
+		case locationFormFile:
+			formContentType = "multipart/form-data"
+			file := &OpenAPISchema{Type: "string", Format: "binary"}
+			if a.typ.Kind() == reflect.Slice {
+				file = &OpenAPISchema{Type: "array", Items: file}
+			}
+			form.Properties[a.name] = file
+		case locationBody:
+			schema := schemas.schemaOf(a.typ)
+			if a.kind == "multipart_reader" {
+				schema = &OpenAPISchema{Type: "object"}
+			}
+			content := map[string]OpenAPIMediaType{a.contentType: {Schema: schema}}
+			if a.kind == "body" {
+				for _, contentType := range bodyContentTypes() {
+					content[contentType] = OpenAPIMediaType{Schema: schema}
+				}
+			}
+			op.RequestBody = &OpenAPIRequestBody{
+				Required: a.required,
+				Content:  content,
+			}
+		}
+	}
+
+	for _, name := range pathParams {
+		if declared[name] {
+			continue
+		}
+		op.Parameters = append(op.Parameters, OpenAPIParameter{
+			Name:     name,
+			In:       "path",
+			Required: true,
+			Schema:   &OpenAPISchema{Type: "string"},
+		})
+	}
+
+	if len(form.Properties) > 0 && op.RequestBody == nil {
+		op.RequestBody = &OpenAPIRequestBody{
+			Content: map[string]OpenAPIMediaType{
+				formContentType: {Schema: form},
+			},
+		}
+	}
+	return op
+}
+
+var openAPILocations = map[paramLocation]string{
+	locationPath:   "path",
+	locationQuery:  "query",
+	locationHeader: "header",
+	locationCookie: "cookie",
+}
+
+func openAPIResponses(e endpointInfo, schemas *schemaRegistry) map[string]OpenAPIResponse {
+	responses := map[string]OpenAPIResponse{
+		"default": {
+			Description: "Error",
+			Content: map[string]OpenAPIMediaType{
+				"application/json": {Schema: schemas.schemaOf(reflect.TypeOf(errorResponse{}))},
+			},
+		},
+	}
+
+	fnType := reflect.TypeOf(e.handler)
+	if fnType.NumOut() == 0 || fnType.Out(0).Implements(errType) {
+		status := e.returnStatus
+		if status == 0 {
+			status = http.StatusOK
+		}
+		responses[strconv.Itoa(status)] = OpenAPIResponse{Description: http.StatusText(status)}
+		return responses
+	}
+
+	out := fnType.Out(0)
+	if fields, ok, _ := parseResponseStruct(out); ok {
+		if fields.body < 0 {
+			responses[strconv.Itoa(http.StatusOK)] = OpenAPIResponse{Description: http.StatusText(http.StatusOK)}
+			return responses
+		}
+		out = fields.structType.Field(fields.body).Type
+	}
+	if isResponseType(out) {
+		responses[strconv.Itoa(http.StatusOK)] = OpenAPIResponse{Description: http.StatusText(http.StatusOK)}
+		return responses
+	}
+	var content map[string]OpenAPIMediaType
+	switch {
+	case out.Kind() == reflect.String:
+		content = map[string]OpenAPIMediaType{"text/plain": {Schema: &OpenAPISchema{Type: "string"}}}
+	case isReaderType(out), isFileType(out):
+		content = map[string]OpenAPIMediaType{"application/octet-stream": {Schema: &OpenAPISchema{Type: "string", Format: "binary"}}}
+	case out.Kind() == reflect.Chan && out.Elem() == eventType:
+		content = map[string]OpenAPIMediaType{"text/event-stream": {Schema: &OpenAPISchema{Type: "string"}}}
+	case isStreamType(out):
+		content = map[string]OpenAPIMediaType{
+			"application/x-ndjson": {Schema: schemas.schemaOf(out.Elem())},
+			"application/json":     {Schema: &OpenAPISchema{Type: "array", Items: schemas.schemaOf(out.Elem())}},
+		}
+	case out == byteType:
+		content = map[string]OpenAPIMediaType{"application/octet-stream": {Schema: &OpenAPISchema{Type: "string", Format: "binary"}}}
+	default:
+		schema := schemas.schemaOf(out)
+		content = map[string]OpenAPIMediaType{}
+		for _, contentType := range codecs.list() {
+			content[contentType] = OpenAPIMediaType{Schema: schema}
+		}
+	}
+	responses[strconv.Itoa(http.StatusOK)] = OpenAPIResponse{Description: http.StatusText(http.StatusOK), Content: content}
+
+	switch out.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Ptr, reflect.Interface, reflect.Chan:
+		responses[strconv.Itoa(http.StatusNoContent)] = OpenAPIResponse{Description: http.StatusText(http.StatusNoContent)}
+	}
+	return responses
+}
+
+// openAPIPath converts a chi pattern into an OpenAPI path and returns names of its url params
+func openAPIPath(pattern string) (string, []string) {
+	var path strings.Builder
+	var params []string
+	for i := 0; i < len(pattern); i++ {
+		if pattern[i] != '{' {
+			path.WriteByte(pattern[i])
+			continue
+		}
+
+		depth := 0
+		end := i
+		for ; end < len(pattern); end++ {
+			if pattern[end] == '{' {
+				depth++
+			} else if pattern[end] == '}' {
+				depth--
+				if depth == 0 {
+					break
+				}
+			}
+		}
+
+		param := pattern[i+1 : end]
+		if colon := strings.Index(param, ":"); colon >= 0 {
+			param = param[:colon]
+		}
+		params = append(params, param)
+		path.WriteString("{" + param + "}")
+		i = end
+	}
+	return path.String(), params
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+type schemaRegistry struct {
+	schemas map[string]*OpenAPISchema
+	names   map[reflect.Type]string
+}
+
+func newSchemaRegistry() *schemaRegistry {
+	return &schemaRegistry{
+		schemas: map[string]*OpenAPISchema{},
+		names:   map[reflect.Type]string{},
+	}
+}
+
+// schemaOf returns a schema of a type. Named structures are stored as components.
+func (s *schemaRegistry) schemaOf(t reflect.Type) *OpenAPISchema {
+	switch t.Kind() {
+	case reflect.Ptr:
+		schema := s.schemaOf(t.Elem())
+		if len(schema.Ref) != 0 {
+			return schema
+		}
+		result := *schema
+		result.Nullable = true
+		return &result
+	case reflect.Bool:
+		return &OpenAPISchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &OpenAPISchema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &OpenAPISchema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &OpenAPISchema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &OpenAPISchema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &OpenAPISchema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &OpenAPISchema{Type: "string", Format: "byte"}
+		}
+		return &OpenAPISchema{Type: "array", Items: s.schemaOf(t.Elem())}
+	case reflect.Map:
+		return &OpenAPISchema{Type: "object", AdditionalProperties: s.schemaOf(t.Elem())}
+	case reflect.Struct:
+		if t == timeType {
+			return &OpenAPISchema{Type: "string", Format: "date-time"}
+		}
+		if len(t.Name()) == 0 {
+			return s.structSchema(t)
+		}
+		return s.namedStructSchema(t)
+	}
+	return &OpenAPISchema{}
+}
+
+func (s *schemaRegistry) namedStructSchema(t reflect.Type) *OpenAPISchema {
+	name, ok := s.names[t]
+	if !ok {
+		name = t.Name()
+		if _, taken := s.schemas[name]; taken {
+			pkg := t.PkgPath()
+			name = pkg[strings.LastIndex(pkg, "/")+1:] + "." + name
+		}
+		s.names[t] = name
+		s.schemas[name] = &OpenAPISchema{}
+		*s.schemas[name] = *s.structSchema(t)
+	}
+	return &OpenAPISchema{Ref: "#/components/schemas/" + name}
+}
+
+func (s *schemaRegistry) structSchema(t reflect.Type) *OpenAPISchema {
+	schema := &OpenAPISchema{Type: "object", Properties: map[string]*OpenAPISchema{}}
+	s.addFields(schema, t)
+	return schema
+}
+
+func (s *schemaRegistry) addFields(schema *OpenAPISchema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, ok := jsonFieldName(f)
+		if !ok {
+			continue
+		}
+		if f.Anonymous && len(name) == 0 {
+			embedded := f.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				s.addFields(schema, embedded)
+				continue
+			}
+		}
+		if len(name) == 0 {
+			name = f.Name
+		}
+		schema.Properties[name] = s.schemaOf(f.Type)
+	}
+}
+
+// jsonFieldName returns a name of a field set by the json tag. Returns false if the field is not encoded.
+func jsonFieldName(f reflect.StructField) (string, bool) {
+	if len(f.PkgPath) != 0 && !f.Anonymous {
+		return "", false
+	}
+	tag := f.Tag.Get("json")
+	if tag == "-" {
+		return "", false
+	}
+	if comma := strings.Index(tag, ","); comma >= 0 {
+		tag = tag[:comma]
+	}
+	return tag, true
+}
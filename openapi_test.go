@@ -0,0 +1,104 @@
+package smartapi_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mmbednarek/smartapi"
+	"github.com/stretchr/testify/require"
+)
+
+type openAPIUser struct {
+	Name    string            `json:"name"`
+	Age     int               `json:"age,omitempty"`
+	Tags    []string          `json:"tags"`
+	Friends []*openAPIUser    `json:"friends"`
+	Meta    map[string]string `json:"meta"`
+	secret  string
+}
+
+func TestOpenAPI(t *testing.T) {
+	r := smartapi.NewRouter()
+	r.Route("/v1", func(r smartapi.Router) {
+		r.Get("/user/{id:[0-9]+}", func(id int, verbose string) (*openAPIUser, error) {
+			return nil, nil
+		},
+			smartapi.AsInt(smartapi.URLParam("id")),
+			smartapi.QueryParam("verbose"),
+		)
+		r.Post("/user", func(session string, u *openAPIUser) error {
+			return nil
+		},
+			smartapi.RequiredHeader("X-Session"),
+			smartapi.JSONBody(openAPIUser{}),
+			smartapi.ResponseStatus(http.StatusCreated),
+		)
+		r.Route("/org/{org}", func(r smartapi.Router) {
+			r.Get("/name", func() string {
+				return ""
+			})
+		})
+	})
+
+	doc := r.OpenAPI(smartapi.OpenAPIInfo{Title: "Test", Version: "1.0.0"})
+	require.Equal(t, "3.0.3", doc.OpenAPI)
+	require.Len(t, doc.Paths, 3)
+
+	get := doc.Paths["/v1/user/{id}"]["get"]
+	require.NotNil(t, get)
+	require.Equal(t, []smartapi.OpenAPIParameter{
+		{Name: "id", In: "path", Required: true, Schema: &smartapi.OpenAPISchema{Type: "integer", Format: "int32"}},
+		{Name: "verbose", In: "query", Schema: &smartapi.OpenAPISchema{Type: "string"}},
+	}, get.Parameters)
+	require.Equal(t, "#/components/schemas/openAPIUser", get.Responses["200"].Content["application/json"].Schema.Ref)
+	require.Contains(t, get.Responses, "204")
+	require.Contains(t, get.Responses, "default")
+
+	post := doc.Paths["/v1/user"]["post"]
+	require.NotNil(t, post)
+	require.Equal(t, "header", post.Parameters[0].In)
+	require.True(t, post.Parameters[0].Required)
+	require.Equal(t, "#/components/schemas/openAPIUser", post.RequestBody.Content["application/json"].Schema.Ref)
+	require.Contains(t, post.Responses, "201")
+
+	name := doc.Paths["/v1/org/{org}/name"]["get"]
+	require.NotNil(t, name)
+	require.Equal(t, "org", name.Parameters[0].Name)
+	require.Equal(t, "text/plain", func() string {
+		for k := range name.Responses["200"].Content {
+			return k
+		}
+		return ""
+	}())
+
+	user := doc.Components.Schemas["openAPIUser"]
+	require.NotNil(t, user)
+	require.Len(t, user.Properties, 5)
+	require.Equal(t, "array", user.Properties["friends"].Type)
+	require.Equal(t, "#/components/schemas/openAPIUser", user.Properties["friends"].Items.Ref)
+	require.Equal(t, "string", user.Properties["meta"].AdditionalProperties.Type)
+}
+
+func TestOpenAPIHandler(t *testing.T) {
+	r := smartapi.NewRouter()
+	r.Handle("/openapi.json", r.OpenAPIHandler(smartapi.OpenAPIInfo{Title: "Test", Version: "1.0.0"}))
+	r.Get("/test", func() error {
+		return nil
+	})
+
+	rr := httptest.NewRecorder()
+	request, err := http.NewRequest("GET", "/openapi.json", nil)
+	require.NoError(t, err)
+	r.MustHandler().ServeHTTP(rr, request)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+
+	var doc smartapi.OpenAPIDocument
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&doc))
+	require.Equal(t, "Test", doc.Info.Title)
+	require.Contains(t, doc.Paths, "/test")
+	require.NotContains(t, doc.Paths, "/openapi.json")
+}
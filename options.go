@@ -0,0 +1,89 @@
+package smartapi
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// endpointConfig holds settings of an endpoint set by endpoint options
+type endpointConfig struct {
+	problemDetails   bool
+	errorMappings    []errorMapping
+	multipart        multipartLimits
+	maxBodySize      int64
+	json             jsonOptions
+	nilPolicy        NilPolicy
+	bufferedResponse bool
+	streamFormat     StreamFormat
+	heartbeat        time.Duration
+	maxMessageSize   int64
+	webSocketOrigins []string
+	panicPolicy      PanicPolicy
+}
+
+// endpointOption is an EndpointParam changing endpoint's settings
+type endpointOption interface {
+	EndpointParam
+	apply(c *endpointConfig)
+}
+
+type endpointConfigKey struct{}
+
+// withEndpointConfig passes endpoint's settings to arguments and error handlers with request's context
+func withEndpointConfig(r *http.Request, config endpointConfig) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), endpointConfigKey{}, config))
+}
+
+func configOf(r *http.Request) endpointConfig {
+	config, _ := r.Context().Value(endpointConfigKey{}).(endpointConfig)
+	return config
+}
+
+// config resolves settings of an endpoint. Endpoint's options take precedence over router's defaults.
+func (e endpointData) config() endpointConfig {
+	var config endpointConfig
+	if e.state != nil {
+		for _, o := range e.state.defaults {
+			o.apply(&config)
+		}
+		config.errorMappings = e.state.errorMappings
+	}
+	for _, o := range e.options {
+		o.apply(&config)
+	}
+	return config
+}
+
+// configOption is an endpoint option changing a single setting
+type configOption func(c *endpointConfig)
+
+func (configOption) options() endpointOptions {
+	return flagEndpointOption
+}
+
+func (o configOption) apply(c *endpointConfig) {
+	o(c)
+}
+
+type problemDetailsOption struct {
+	enabled bool
+}
+
+func (problemDetailsOption) options() endpointOptions {
+	return flagEndpointOption
+}
+
+func (p problemDetailsOption) apply(c *endpointConfig) {
+	c.problemDetails = p.enabled
+}
+
+// ProblemDetails makes errors to be written as RFC 7807 problem details with application/problem+json content type
+func ProblemDetails() EndpointParam {
+	return problemDetailsOption{enabled: true}
+}
+
+// LegacyErrors makes errors to be written as {"status","reason"} objects. This is the default.
+func LegacyErrors() EndpointParam {
+	return problemDetailsOption{enabled: false}
+}
@@ -0,0 +1,240 @@
+package smartapi
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// ListFormat selects how multiple values of a param are passed in the request
+type ListFormat int
+
+const (
+	// RepeatedKeys passes every value with the same key, for example tag=a&tag=b. This is the default.
+	RepeatedKeys ListFormat = iota
+	// CommaSeparated passes values as a comma separated list, for example tag=a,b
+	CommaSeparated
+	// BracketKeys passes every value with a key suffixed with brackets, for example tag[]=a&tag[]=b
+	BracketKeys
+)
+
+// paramOptions holds settings of a param read from the request
+type paramOptions struct {
+	format        ListFormat
+	defaultValue  string
+	hasDefault    bool
+	rejectUnknown bool
+}
+
+// ParamOption changes how a param is read from the request
+type ParamOption func(o *paramOptions)
+
+// Format sets the format of values of a param bound to a slice
+func Format(format ListFormat) ParamOption {
+	return func(o *paramOptions) {
+		o.format = format
+	}
+}
+
+// Default sets the value of a param used when the param is absent in the request
+func Default(value string) ParamOption {
+	return func(o *paramOptions) {
+		o.defaultValue = value
+		o.hasDefault = true
+	}
+}
+
+// RejectUnknownKeys makes QueryStruct return 400 BAD REQUEST if the query contains params not bound to any field
+func RejectUnknownKeys() ParamOption {
+	return func(o *paramOptions) {
+		o.rejectUnknown = true
+	}
+}
+
+func newParamOptions(options []ParamOption) paramOptions {
+	var result paramOptions
+	for _, o := range options {
+		o(&result)
+	}
+	return result
+}
+
+// paramValue returns the first value of a param, the default value is returned if the param is absent
+func paramValue(values []string, opts paramOptions) (string, bool) {
+	if len(values) == 0 {
+		return opts.defaultValue, opts.hasDefault
+	}
+	return values[0], true
+}
+
+// listValues returns values of a param in a list format. Empty values are skipped.
+func listValues(values map[string][]string, name string, opts paramOptions) []string {
+	key := name
+	if opts.format == BracketKeys {
+		key += "[]"
+	}
+	raw, ok := values[key]
+	if !ok && opts.hasDefault {
+		raw = []string{opts.defaultValue}
+	}
+	if opts.format == CommaSeparated {
+		var split []string
+		for _, v := range raw {
+			split = append(split, strings.Split(v, ",")...)
+		}
+		raw = split
+	}
+
+	result := make([]string, 0, len(raw))
+	for _, v := range raw {
+		if opts.format == CommaSeparated {
+			v = strings.TrimSpace(v)
+		}
+		if len(v) != 0 {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+// checkListArg checks if strings can be converted to the argument's type or the type of its elements
+func checkListArg(arg reflect.Type) error {
+	if arg.Kind() == reflect.Slice && arg != byteType {
+		if _, ok := converterOf(arg.Elem()); ok {
+			return nil
+		}
+	}
+	return checkStringArg(arg)
+}
+
+// listArgument is a string argument which can have multiple values
+type listArgument interface {
+	stringArgument
+	// lookup returns all values of the param
+	lookup(r *http.Request) []string
+}
+
+// sliceArgument passes all values of a list argument as a slice
+type sliceArgument struct {
+	arg     listArgument
+	typ     reflect.Type
+	convert converter
+}
+
+func (s sliceArgument) options() endpointOptions {
+	return s.arg.options()
+}
+
+func (s sliceArgument) checkArg(arg reflect.Type) error {
+	if arg != s.typ {
+		return fmt.Errorf("expected %s type", s.typ)
+	}
+	return nil
+}
+
+func (s sliceArgument) getValue(w http.ResponseWriter, r *http.Request) (reflect.Value, error) {
+	values := s.arg.lookup(r)
+	if len(values) == 0 {
+		if s.arg.isRequired() {
+			return reflect.Value{}, missingParamError(s.arg.describe())
+		}
+		return reflect.Zero(s.typ), nil
+	}
+
+	result := reflect.MakeSlice(s.typ, 0, len(values))
+	for _, value := range values {
+		v, err := s.convert(value)
+		if err != nil {
+			return reflect.Value{}, invalidParamError(s.arg.describe(), err)
+		}
+		result = reflect.Append(result, v)
+	}
+	return result, nil
+}
+
+// pointerArgument passes a pointer to a value of a string argument, nil is passed if the param is absent
+type pointerArgument struct {
+	arg     stringArgument
+	typ     reflect.Type
+	convert converter
+}
+
+func (p pointerArgument) options() endpointOptions {
+	return p.arg.options()
+}
+
+func (p pointerArgument) checkArg(arg reflect.Type) error {
+	if arg != p.typ {
+		return fmt.Errorf("expected %s type", p.typ)
+	}
+	return nil
+}
+
+func (p pointerArgument) getValue(w http.ResponseWriter, r *http.Request) (reflect.Value, error) {
+	value, ok := p.arg.value(r)
+	if len(value) == 0 && p.arg.isRequired() {
+		return reflect.Value{}, missingParamError(p.arg.describe())
+	}
+	if !ok {
+		return reflect.Zero(p.typ), nil
+	}
+
+	v, err := p.convert(value)
+	if err != nil {
+		return reflect.Value{}, invalidParamError(p.arg.describe(), err)
+	}
+	result := reflect.New(p.typ.Elem())
+	result.Elem().Set(v)
+	return result, nil
+}
+
+func missingParamError(param string) error {
+	msg := fmt.Sprintf("missing required %s", param)
+	return Error(http.StatusBadRequest, msg, msg)
+}
+
+func invalidParamError(param string, err error) error {
+	msg := fmt.Sprintf("invalid value of %s", param)
+	return WrapError(http.StatusBadRequest, fmt.Errorf("%s: %w", msg, err), msg)
+}
+
+// parseParamTag parses tag's data of a param in the form of name[,format=csv][,default=value]
+func parseParamTag(data string) (string, []ParamOption, error) {
+	parts := strings.Split(data, ",")
+	var options []ParamOption
+	for i, part := range parts[1:] {
+		eqAt := strings.Index(part, "=")
+		if eqAt < 0 {
+			return "", nil, fmt.Errorf("invalid param option %s", part)
+		}
+		key, value := part[:eqAt], part[eqAt+1:]
+		switch key {
+		case "default":
+			// default value is the last option, it can contain commas
+			value = strings.Join(append([]string{value}, parts[i+2:]...), ",")
+			return parts[0], append(options, Default(value)), nil
+		case "format":
+			format, err := parseListFormat(value)
+			if err != nil {
+				return "", nil, err
+			}
+			options = append(options, Format(format))
+		default:
+			return "", nil, fmt.Errorf("unknown param option %s", key)
+		}
+	}
+	return parts[0], options, nil
+}
+
+func parseListFormat(format string) (ListFormat, error) {
+	switch format {
+	case "repeated":
+		return RepeatedKeys, nil
+	case "csv":
+		return CommaSeparated, nil
+	case "brackets":
+		return BracketKeys, nil
+	}
+	return 0, fmt.Errorf("unknown list format %s", format)
+}
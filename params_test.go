@@ -0,0 +1,344 @@
+package smartapi_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/mmbednarek/smartapi"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListParams(t *testing.T) {
+	tests := []struct {
+		name         string
+		api          func(r smartapi.Router)
+		request      func() *http.Request
+		responseCode int
+		responseBody string
+	}{
+		{
+			name: "Repeated keys",
+			api: func(r smartapi.Router) {
+				r.Get("/test", func(tags []string, ids []int) {
+					require.Equal(t, []string{"a", "b"}, tags)
+					require.Equal(t, []int{1, 2, 3}, ids)
+				},
+					smartapi.QueryParam("tag"),
+					smartapi.RequiredQueryParam("id"),
+				)
+			},
+			request: func() *http.Request {
+				req, _ := http.NewRequest("GET", "/test?tag=a&tag=b&id=1&id=2&id=3", nil)
+				return req
+			},
+			responseCode: http.StatusNoContent,
+		},
+		{
+			name: "Comma separated",
+			api: func(r smartapi.Router) {
+				r.Get("/test", func(ids []uint, encodings []string) {
+					require.Equal(t, []uint{1, 2, 3}, ids)
+					require.Equal(t, []string{"gzip", "deflate", "br"}, encodings)
+				},
+					smartapi.QueryParam("id", smartapi.Format(smartapi.CommaSeparated)),
+					smartapi.Header("Accept-Encoding", smartapi.Format(smartapi.CommaSeparated)),
+				)
+			},
+			request: func() *http.Request {
+				req, _ := http.NewRequest("GET", "/test?id=1,2&id=3", nil)
+				req.Header.Set("Accept-Encoding", "gzip, deflate")
+				req.Header.Add("Accept-Encoding", "br")
+				return req
+			},
+			responseCode: http.StatusNoContent,
+		},
+		{
+			name: "Brackets",
+			api: func(r smartapi.Router) {
+				r.Post("/test", func(tags []string, colors []string) {
+					require.Equal(t, []string{"a", "b"}, tags)
+					require.Equal(t, []string{"red", "blue"}, colors)
+				},
+					smartapi.PostQueryParam("tag", smartapi.Format(smartapi.BracketKeys)),
+					smartapi.RequiredPostQueryParam("color", smartapi.Format(smartapi.BracketKeys)),
+				)
+			},
+			request: func() *http.Request {
+				form := url.Values{"tag[]": {"a", "b"}, "color[]": {"red", "blue"}, "tag": {"c"}}
+				req, _ := http.NewRequest("POST", "/test", strings.NewReader(form.Encode()))
+				req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+				return req
+			},
+			responseCode: http.StatusNoContent,
+		},
+		{
+			name: "Request struct",
+			api: func(r smartapi.Router) {
+				type request struct {
+					Tags   []string `smartapi:"query_param=tag,format=csv"`
+					Scopes []string `smartapi:"r_header=X-Scope"`
+					Sizes  []int    `smartapi:"query_param=size,format=brackets"`
+				}
+				r.Get("/test", func(rq *request) {
+					require.Equal(t, &request{
+						Tags:   []string{"a", "b"},
+						Scopes: []string{"read", "write"},
+					}, rq)
+				},
+					smartapi.RequestStruct(request{}),
+				)
+			},
+			request: func() *http.Request {
+				req, _ := http.NewRequest("GET", "/test?tag=a,b", nil)
+				req.Header.Add("X-Scope", "read")
+				req.Header.Add("X-Scope", "write")
+				return req
+			},
+			responseCode: http.StatusNoContent,
+		},
+		{
+			name: "Missing required",
+			api: func(r smartapi.Router) {
+				r.Get("/test", func(ids []int) {}, smartapi.RequiredQueryParam("id"))
+			},
+			request: func() *http.Request {
+				req, _ := http.NewRequest("GET", "/test?id=", nil)
+				return req
+			},
+			responseCode: http.StatusBadRequest,
+			responseBody: `{"status":400,"reason":"missing required query param id"}` + "\n",
+		},
+		{
+			name: "Invalid value",
+			api: func(r smartapi.Router) {
+				r.Get("/test", func(ids []int) {}, smartapi.QueryParam("id"))
+			},
+			request: func() *http.Request {
+				req, _ := http.NewRequest("GET", "/test?id=1&id=two", nil)
+				return req
+			},
+			responseCode: http.StatusBadRequest,
+			responseBody: `{"status":400,"reason":"invalid value of query param id"}` + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := smartapi.NewRouterLogger(nil)
+			tt.api(r)
+
+			rr := httptest.NewRecorder()
+			r.MustHandler().ServeHTTP(rr, tt.request())
+
+			require.Equal(t, tt.responseCode, rr.Code)
+			require.Equal(t, tt.responseBody, rr.Body.String())
+		})
+	}
+}
+
+func TestListParamsErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		api  func(r smartapi.Router)
+		err  string
+	}{
+		{
+			name: "Unsupported element",
+			api: func(r smartapi.Router) {
+				r.Get("/test", func([]map[string]string) {}, smartapi.QueryParam("id"))
+			},
+			err: "endpoint /test: (argument 0) cannot convert a string to []map[string]string",
+		},
+		{
+			name: "Single valued param",
+			api: func(r smartapi.Router) {
+				r.Get("/test", func([]string) {}, smartapi.Cookie("id"))
+			},
+			err: "endpoint /test: (argument 0) cannot convert a string to []string",
+		},
+		{
+			name: "Unknown format",
+			api: func(r smartapi.Router) {
+				type request struct {
+					Tags []string `smartapi:"query_param=tag,format=pipes"`
+				}
+				r.Get("/test", func(*request) {}, smartapi.RequestStruct(request{}))
+			},
+			err: "endpoint /test: (argument 0) (struct field Tags) unknown list format pipes",
+		},
+		{
+			name: "Unknown option",
+			api: func(r smartapi.Router) {
+				type request struct {
+					Tags []string `smartapi:"query_param=tag,separator=;"`
+				}
+				r.Get("/test", func(*request) {}, smartapi.RequestStruct(request{}))
+			},
+			err: "endpoint /test: (argument 0) (struct field Tags) unknown param option separator",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := smartapi.NewRouterLogger(nil)
+			tt.api(r)
+			_, err := r.Handler()
+			require.EqualError(t, err, tt.err)
+		})
+	}
+}
+
+func TestDefaultParams(t *testing.T) {
+	intPtr := func(v int) *int {
+		return &v
+	}
+	strPtr := func(v string) *string {
+		return &v
+	}
+
+	tests := []struct {
+		name         string
+		api          func(r smartapi.Router)
+		request      func() *http.Request
+		responseCode int
+		responseBody string
+	}{
+		{
+			name: "Defaults",
+			api: func(r smartapi.Router) {
+				r.Get("/test", func(page int, lang string, theme string, tags []string) {
+					require.Equal(t, 1, page)
+					require.Equal(t, "en", lang)
+					require.Equal(t, "dark", theme)
+					require.Equal(t, []string{"a", "b"}, tags)
+				},
+					smartapi.QueryParam("page", smartapi.Default("1")),
+					smartapi.Header("Accept-Language", smartapi.Default("en")),
+					smartapi.Cookie("theme", smartapi.Default("dark")),
+					smartapi.QueryParam("tag", smartapi.Format(smartapi.CommaSeparated), smartapi.Default("a,b")),
+				)
+			},
+			request: func() *http.Request {
+				req, _ := http.NewRequest("GET", "/test", nil)
+				return req
+			},
+			responseCode: http.StatusNoContent,
+		},
+		{
+			name: "Present values",
+			api: func(r smartapi.Router) {
+				r.Get("/test", func(page int, lang string, theme string) {
+					require.Equal(t, 3, page)
+					require.Equal(t, "", lang)
+					require.Equal(t, "light", theme)
+				},
+					smartapi.QueryParam("page", smartapi.Default("1")),
+					smartapi.QueryParam("lang", smartapi.Default("en")),
+					smartapi.RequiredCookie("theme", smartapi.Default("dark")),
+				)
+			},
+			request: func() *http.Request {
+				req, _ := http.NewRequest("GET", "/test?page=3&lang=", nil)
+				req.AddCookie(&http.Cookie{Name: "theme", Value: "light"})
+				return req
+			},
+			responseCode: http.StatusNoContent,
+		},
+		{
+			name: "Pointers",
+			api: func(r smartapi.Router) {
+				r.Get("/test", func(page *int, limit *int, name *string, session *string) {
+					require.Nil(t, page)
+					require.Equal(t, intPtr(5), limit)
+					require.Equal(t, strPtr(""), name)
+					require.Nil(t, session)
+				},
+					smartapi.QueryParam("page"),
+					smartapi.QueryParam("limit"),
+					smartapi.QueryParam("name"),
+					smartapi.Cookie("session"),
+				)
+			},
+			request: func() *http.Request {
+				req, _ := http.NewRequest("GET", "/test?limit=5&name=", nil)
+				return req
+			},
+			responseCode: http.StatusNoContent,
+		},
+		{
+			name: "Request struct",
+			api: func(r smartapi.Router) {
+				type request struct {
+					Page    int      `smartapi:"query_param=page,default=1"`
+					Limit   *int     `smartapi:"query_param=limit"`
+					Tags    []string `smartapi:"query_param=tag,format=csv,default=a,b"`
+					Session string   `smartapi:"r_cookie=session"`
+				}
+				r.Get("/test", func(rq *request) {
+					require.Equal(t, &request{Page: 1, Tags: []string{"a", "b"}, Session: "abc"}, rq)
+				},
+					smartapi.RequestStruct(request{}),
+				)
+			},
+			request: func() *http.Request {
+				req, _ := http.NewRequest("GET", "/test", nil)
+				req.AddCookie(&http.Cookie{Name: "session", Value: "abc"})
+				return req
+			},
+			responseCode: http.StatusNoContent,
+		},
+		{
+			name: "Missing required pointer",
+			api: func(r smartapi.Router) {
+				r.Get("/test", func(page *int) {}, smartapi.RequiredQueryParam("page"))
+			},
+			request: func() *http.Request {
+				req, _ := http.NewRequest("GET", "/test", nil)
+				return req
+			},
+			responseCode: http.StatusBadRequest,
+			responseBody: `{"status":400,"reason":"missing required query param page"}` + "\n",
+		},
+		{
+			name: "Invalid pointer",
+			api: func(r smartapi.Router) {
+				r.Get("/test", func(page *int) {}, smartapi.Header("X-Page"))
+			},
+			request: func() *http.Request {
+				req, _ := http.NewRequest("GET", "/test", nil)
+				req.Header.Set("X-Page", "first")
+				return req
+			},
+			responseCode: http.StatusBadRequest,
+			responseBody: `{"status":400,"reason":"invalid value of header X-Page"}` + "\n",
+		},
+		{
+			name: "Invalid default",
+			api: func(r smartapi.Router) {
+				r.Get("/test", func(page int) {}, smartapi.QueryParam("page", smartapi.Default("first")))
+			},
+			request: func() *http.Request {
+				req, _ := http.NewRequest("GET", "/test", nil)
+				return req
+			},
+			responseCode: http.StatusBadRequest,
+			responseBody: `{"status":400,"reason":"invalid value of query param page"}` + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := smartapi.NewRouterLogger(nil)
+			tt.api(r)
+
+			rr := httptest.NewRecorder()
+			r.MustHandler().ServeHTTP(rr, tt.request())
+
+			require.Equal(t, tt.responseCode, rr.Code)
+			require.Equal(t, tt.responseBody, rr.Body.String())
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package smartapi
+
+import (
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"sort"
+)
+
+const problemNamespace = "urn:ietf:rfc:7807"
+
+// problemResponse contains RFC 7807 problem details members
+type problemResponse map[string]interface{}
+
+func problemOf(apiErr ApiError, err error) problemResponse {
+	problem := problemResponse{
+		"type":   "about:blank",
+		"title":  apiErr.Reason(),
+		"status": apiErr.Status(),
+	}
+
+	var validationErr ValidationError
+	if errors.As(err, &validationErr) {
+		problem["errors"] = validationErr.Fields
+	}
+
+	var detailedErr *DetailedError
+	if !errors.As(err, &detailedErr) {
+		return problem
+	}
+	for k, v := range detailedErr.Extensions {
+		problem[k] = v
+	}
+	if len(detailedErr.Type) != 0 {
+		problem["type"] = detailedErr.Type
+	}
+	if len(detailedErr.Detail) != 0 {
+		problem["detail"] = detailedErr.Detail
+	}
+	if len(detailedErr.Instance) != 0 {
+		problem["instance"] = detailedErr.Instance
+	}
+	problem["title"] = detailedErr.Title
+	problem["status"] = detailedErr.StatusCode
+	return problem
+}
+
+// MarshalXML writes problem details in the application/problem+xml format
+func (p problemResponse) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	start = xml.StartElement{Name: xml.Name{Space: problemNamespace, Local: "problem"}}
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(p))
+	for k := range p {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		if err := e.EncodeElement(p[k], xml.StartElement{Name: xml.Name{Local: k}}); err != nil {
+			return err
+		}
+	}
+	return e.EncodeToken(start.End())
+}
+
+func writeProblem(w http.ResponseWriter, r *http.Request, problem problemResponse) {
+	contentType, codec := errorCodec(r)
+	switch contentType {
+	case "application/json":
+		contentType = "application/problem+json"
+	case "application/xml", "text/xml":
+		contentType = "application/problem+xml"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(problem["status"].(int))
+	_ = codec.Encode(w, problem)
+}
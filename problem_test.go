@@ -0,0 +1,150 @@
+package smartapi_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mmbednarek/smartapi"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProblemDetails(t *testing.T) {
+	type user struct {
+		Name string `json:"name" validate:"required"`
+	}
+
+	tests := []struct {
+		name         string
+		api          func(r smartapi.Router)
+		request      func() *http.Request
+		responseCode int
+		contentType  string
+		responseBody string
+	}{
+		{
+			name: "Problem",
+			api: func(r smartapi.Router) {
+				r.Defaults(smartapi.ProblemDetails())
+				r.Get("/test", func() error {
+					return smartapi.Problem(http.StatusForbidden, "https://example.com/probs/out-of-credit",
+						"You do not have enough credit.", "Your current balance is 30, but that costs 50.").
+						With("balance", 30)
+				})
+			},
+			request: func() *http.Request {
+				req, _ := http.NewRequest("GET", "/test", nil)
+				return req
+			},
+			responseCode: http.StatusForbidden,
+			contentType:  "application/problem+json",
+			responseBody: `{"balance":30,"detail":"Your current balance is 30, but that costs 50.","status":403,` +
+				`"title":"You do not have enough credit.","type":"https://example.com/probs/out-of-credit"}` + "\n",
+		},
+		{
+			name: "ApiError",
+			api: func(r smartapi.Router) {
+				r.Get("/test", func() error {
+					return smartapi.Error(http.StatusNotFound, "no such user", "user not found")
+				}, smartapi.ProblemDetails())
+			},
+			request: func() *http.Request {
+				req, _ := http.NewRequest("GET", "/test", nil)
+				return req
+			},
+			responseCode: http.StatusNotFound,
+			contentType:  "application/problem+json",
+			responseBody: `{"status":404,"title":"user not found","type":"about:blank"}` + "\n",
+		},
+		{
+			name: "Validation",
+			api: func(r smartapi.Router) {
+				r.Route("/v1", func(r smartapi.Router) {
+					r.Post("/test", func(u *user) error {
+						return nil
+					}, smartapi.JSONBody(user{}))
+				}, smartapi.ProblemDetails())
+			},
+			request: func() *http.Request {
+				req, _ := http.NewRequest("POST", "/v1/test", bytes.NewReader([]byte(`{}`)))
+				return req
+			},
+			responseCode: http.StatusUnprocessableEntity,
+			contentType:  "application/problem+json",
+			responseBody: `{"errors":[{"field":"name","rule":"required","value":""}],"status":422,` +
+				`"title":"validation failed","type":"about:blank"}` + "\n",
+		},
+		{
+			name: "XML",
+			api: func(r smartapi.Router) {
+				r.Defaults(smartapi.ProblemDetails())
+				r.Get("/test", func() error {
+					return smartapi.WithErrorCode(smartapi.Error(http.StatusConflict, "conflict", "user exists"), "E42")
+				})
+			},
+			request: func() *http.Request {
+				req, _ := http.NewRequest("GET", "/test", nil)
+				req.Header.Set("Accept", "application/xml")
+				return req
+			},
+			responseCode: http.StatusConflict,
+			contentType:  "application/problem+xml",
+			responseBody: `<problem xmlns="urn:ietf:rfc:7807"><code>E42</code><status>409</status>` +
+				`<title>user exists</title><type>about:blank</type></problem>`,
+		},
+		{
+			name: "Endpoint overrides defaults",
+			api: func(r smartapi.Router) {
+				r.Defaults(smartapi.ProblemDetails())
+				r.Get("/test", func() error {
+					return smartapi.WithErrorCode(smartapi.Error(http.StatusConflict, "conflict", "user exists"), "E42")
+				}, smartapi.LegacyErrors())
+			},
+			request: func() *http.Request {
+				req, _ := http.NewRequest("GET", "/test", nil)
+				return req
+			},
+			responseCode: http.StatusConflict,
+			contentType:  "application/json",
+			responseBody: `{"status":409,"reason":"user exists","details":{"code":"E42"}}` + "\n",
+		},
+		{
+			name: "Legacy field errors",
+			api: func(r smartapi.Router) {
+				r.Get("/test", func() error {
+					return smartapi.WithFieldErrors(smartapi.Error(http.StatusBadRequest, "invalid", "invalid user"),
+						smartapi.FieldError{Field: "name", Rule: "unique", Value: "john"})
+				})
+			},
+			request: func() *http.Request {
+				req, _ := http.NewRequest("GET", "/test", nil)
+				return req
+			},
+			responseCode: http.StatusBadRequest,
+			contentType:  "application/json",
+			responseBody: `{"status":400,"reason":"invalid user","errors":[{"field":"name","rule":"unique","value":"john"}]}` + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := smartapi.NewRouterLogger(nil)
+			tt.api(r)
+
+			rr := httptest.NewRecorder()
+			r.MustHandler().ServeHTTP(rr, tt.request())
+
+			require.Equal(t, tt.responseCode, rr.Code)
+			require.Equal(t, tt.contentType, rr.Header().Get("Content-Type"))
+			require.Equal(t, tt.responseBody, rr.Body.String())
+		})
+	}
+}
+
+func TestDefaultsError(t *testing.T) {
+	r := smartapi.NewRouter()
+	r.Defaults(smartapi.QueryParam("test"))
+	_, err := r.Handler()
+	require.EqualError(t, err, "defaults: (param 0) only endpoint options can be used as defaults")
+}
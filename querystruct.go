@@ -0,0 +1,254 @@
+package smartapi
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+const queryTagName = "query"
+
+type queryBinding int
+
+const (
+	bindValue queryBinding = iota
+	bindSlice
+	bindPointer
+)
+
+// queryField is a field of a query struct bound to a query param
+type queryField struct {
+	key     string
+	index   []int
+	typ     reflect.Type
+	convert converter
+	binding queryBinding
+}
+
+type queryStructArgument struct {
+	structType reflect.Type
+	fields     []queryField
+	keys       map[string]struct{}
+	opts       paramOptions
+}
+
+func (q queryStructArgument) options() endpointOptions {
+	return flagArgument
+}
+
+func (q queryStructArgument) checkArg(arg reflect.Type) error {
+	if arg.Kind() != reflect.Ptr {
+		return errors.New("argument must be a pointer")
+	}
+	if q.structType != arg.Elem() {
+		return errors.New("invalid argument type")
+	}
+	return nil
+}
+
+func (q queryStructArgument) getValue(w http.ResponseWriter, r *http.Request) (reflect.Value, error) {
+	return q.construct(r.URL.Query())
+}
+
+func (q queryStructArgument) construct(values map[string][]string) (reflect.Value, error) {
+	if q.opts.rejectUnknown {
+		for key := range values {
+			if q.opts.format == BracketKeys {
+				key = strings.TrimSuffix(key, "[]")
+			}
+			if _, ok := q.keys[key]; !ok {
+				msg := fmt.Sprintf("unknown query param %s", key)
+				return reflect.Value{}, Error(http.StatusBadRequest, msg, msg)
+			}
+		}
+	}
+
+	vPtr := reflect.New(q.structType)
+	vStruct := vPtr.Elem()
+	for _, f := range q.fields {
+		fieldValue, err := f.value(values, q.opts)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		if fieldValue.IsValid() {
+			vStruct.FieldByIndex(f.index).Set(fieldValue)
+		}
+	}
+	if err := validate(vPtr); err != nil {
+		return reflect.Value{}, err
+	}
+	return vPtr, nil
+}
+
+// value returns the value of a field or an invalid value if the param is absent
+func (f queryField) value(values map[string][]string, opts paramOptions) (reflect.Value, error) {
+	switch f.binding {
+	case bindSlice:
+		raw := listValues(values, f.key, paramOptions{format: opts.format})
+		if len(raw) == 0 {
+			return reflect.Value{}, nil
+		}
+		result := reflect.MakeSlice(f.typ, 0, len(raw))
+		for _, value := range raw {
+			v, err := f.convert(value)
+			if err != nil {
+				return reflect.Value{}, invalidParamError("query param "+f.key, err)
+			}
+			result = reflect.Append(result, v)
+		}
+		return result, nil
+	case bindPointer:
+		raw, ok := values[f.key]
+		if !ok || len(raw) == 0 {
+			return reflect.Value{}, nil
+		}
+		v, err := f.convert(raw[0])
+		if err != nil {
+			return reflect.Value{}, invalidParamError("query param "+f.key, err)
+		}
+		result := reflect.New(f.typ.Elem())
+		result.Elem().Set(v)
+		return result, nil
+	}
+
+	raw := values[f.key]
+	if len(raw) == 0 || len(raw[0]) == 0 {
+		return reflect.Value{}, nil
+	}
+	v, err := f.convert(raw[0])
+	if err != nil {
+		return reflect.Value{}, invalidParamError("query param "+f.key, err)
+	}
+	return v, nil
+}
+
+type queryStructDirectArgument queryStructArgument
+
+func (q queryStructDirectArgument) options() endpointOptions {
+	return flagArgument
+}
+
+func (q queryStructDirectArgument) checkArg(arg reflect.Type) error {
+	if q.structType != arg {
+		return errors.New("invalid argument type")
+	}
+	return nil
+}
+
+func (q queryStructDirectArgument) getValue(w http.ResponseWriter, r *http.Request) (reflect.Value, error) {
+	v, err := queryStructArgument(q).construct(r.URL.Query())
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	return v.Elem(), nil
+}
+
+// queryFields returns fields of a struct bound to query params. Nested structs are bound to keys prefixed with field's key and a dot.
+func queryFields(structType reflect.Type, prefix string, index []int) ([]queryField, error) {
+	var result []queryField
+	for i := 0; i < structType.NumField(); i++ {
+		f := structType.Field(i)
+		if len(f.PkgPath) != 0 && !f.Anonymous {
+			continue
+		}
+
+		tag := f.Tag.Get(queryTagName)
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		fieldIndex := append(append([]int{}, index...), i)
+
+		if f.Anonymous && len(name) == 0 && f.Type.Kind() == reflect.Struct {
+			fields, err := queryFields(f.Type, prefix, fieldIndex)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, fields...)
+			continue
+		}
+		if len(f.PkgPath) != 0 {
+			continue
+		}
+		if len(name) == 0 {
+			name = f.Name
+		}
+		key := prefix + name
+
+		field := queryField{key: key, index: fieldIndex, typ: f.Type}
+		if convert, ok := converterOf(f.Type); ok {
+			field.convert = convert
+			result = append(result, field)
+			continue
+		}
+
+		switch f.Type.Kind() {
+		case reflect.Struct:
+			fields, err := queryFields(f.Type, key+".", fieldIndex)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, fields...)
+			continue
+		case reflect.Slice, reflect.Ptr:
+			if convert, ok := converterOf(f.Type.Elem()); ok && f.Type != byteType {
+				field.convert = convert
+				field.binding = bindSlice
+				if f.Type.Kind() == reflect.Ptr {
+					field.binding = bindPointer
+				}
+				result = append(result, field)
+				continue
+			}
+		}
+		return nil, fmt.Errorf("(struct field %s) cannot convert a string to %s", f.Name, f.Type)
+	}
+	return result, nil
+}
+
+func queryStruct(structType reflect.Type, options []ParamOption) (queryStructArgument, error) {
+	if structType == nil || structType.Kind() != reflect.Struct {
+		return queryStructArgument{}, errors.New("QueryStruct's argument must be a structure")
+	}
+
+	fields, err := queryFields(structType, "", nil)
+	if err != nil {
+		return queryStructArgument{}, err
+	}
+	if err := checkValidation(structType); err != nil {
+		return queryStructArgument{}, err
+	}
+
+	keys := make(map[string]struct{}, len(fields))
+	for _, f := range fields {
+		keys[f.key] = struct{}{}
+	}
+
+	return queryStructArgument{
+		structType: structType,
+		fields:     fields,
+		keys:       keys,
+		opts:       newParamOptions(options),
+	}, nil
+}
+
+// QueryStruct binds query params into a structure's fields by query tags and passes a pointer to the structure.
+// Fields of nested structures are bound to dotted keys, for example filter.age_min.
+func QueryStruct(s interface{}, options ...ParamOption) EndpointParam {
+	q, err := queryStruct(reflect.TypeOf(s), options)
+	if err != nil {
+		return errorEndpointParam{err: err}
+	}
+	return q
+}
+
+// QueryStructDirect binds query params into a structure's fields by query tags and passes the structure
+func QueryStructDirect(s interface{}, options ...ParamOption) EndpointParam {
+	q, err := queryStruct(reflect.TypeOf(s), options)
+	if err != nil {
+		return errorEndpointParam{err: err}
+	}
+	return queryStructDirectArgument(q)
+}
@@ -0,0 +1,174 @@
+package smartapi_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mmbednarek/smartapi"
+	"github.com/stretchr/testify/require"
+)
+
+type queryTestPage struct {
+	Page  int  `query:"page"`
+	Limit *int `query:"limit"`
+}
+
+type queryTestFilter struct {
+	queryTestPage
+	Name   string    `query:"name"`
+	Tags   []string  `query:"tag"`
+	Since  time.Time `query:"since"`
+	Ignore string    `query:"-"`
+	Age    struct {
+		Min uint `query:"min"`
+		Max uint `query:"max"`
+	} `query:"age"`
+}
+
+func TestQueryStruct(t *testing.T) {
+	limit := 20
+
+	tests := []struct {
+		name         string
+		api          func(r smartapi.Router)
+		request      func() *http.Request
+		responseCode int
+		responseBody string
+	}{
+		{
+			name: "QueryStruct",
+			api: func(r smartapi.Router) {
+				r.Get("/test", func(f *queryTestFilter) {
+					expected := &queryTestFilter{
+						queryTestPage: queryTestPage{Page: 2, Limit: &limit},
+						Name:          "John",
+						Tags:          []string{"a", "b"},
+						Since:         time.Date(2020, 3, 22, 0, 0, 0, 0, time.UTC),
+					}
+					expected.Age.Min = 18
+					expected.Age.Max = 30
+					require.Equal(t, expected, f)
+				},
+					smartapi.QueryStruct(queryTestFilter{}),
+				)
+			},
+			request: func() *http.Request {
+				req, _ := http.NewRequest("GET", "/test?page=2&limit=20&name=John&tag=a&tag=b"+
+					"&since=2020-03-22T00:00:00Z&age.min=18&age.max=30&Ignore=x", nil)
+				return req
+			},
+			responseCode: http.StatusNoContent,
+		},
+		{
+			name: "QueryStructDirect",
+			api: func(r smartapi.Router) {
+				r.Get("/test", func(f queryTestFilter) {
+					require.Equal(t, queryTestFilter{Tags: []string{"a", "b"}}, f)
+				},
+					smartapi.QueryStructDirect(queryTestFilter{}, smartapi.Format(smartapi.CommaSeparated)),
+				)
+			},
+			request: func() *http.Request {
+				req, _ := http.NewRequest("GET", "/test?tag=a,b", nil)
+				return req
+			},
+			responseCode: http.StatusNoContent,
+		},
+		{
+			name: "Invalid value",
+			api: func(r smartapi.Router) {
+				r.Get("/test", func(f *queryTestFilter) {}, smartapi.QueryStruct(queryTestFilter{}))
+			},
+			request: func() *http.Request {
+				req, _ := http.NewRequest("GET", "/test?age.min=young", nil)
+				return req
+			},
+			responseCode: http.StatusBadRequest,
+			responseBody: `{"status":400,"reason":"invalid value of query param age.min"}` + "\n",
+		},
+		{
+			name: "Unknown key",
+			api: func(r smartapi.Router) {
+				r.Get("/test", func(f *queryTestPage) {}, smartapi.QueryStruct(queryTestPage{}, smartapi.RejectUnknownKeys()))
+			},
+			request: func() *http.Request {
+				req, _ := http.NewRequest("GET", "/test?page=1&size=10", nil)
+				return req
+			},
+			responseCode: http.StatusBadRequest,
+			responseBody: `{"status":400,"reason":"unknown query param size"}` + "\n",
+		},
+		{
+			name: "Validation",
+			api: func(r smartapi.Router) {
+				type query struct {
+					Name string `query:"name" validate:"required"`
+				}
+				r.Get("/test", func(q *query) {}, smartapi.QueryStruct(query{}))
+			},
+			request: func() *http.Request {
+				req, _ := http.NewRequest("GET", "/test", nil)
+				return req
+			},
+			responseCode: http.StatusUnprocessableEntity,
+			responseBody: `{"status":422,"reason":"validation failed","errors":[{"field":"Name","rule":"required","value":""}]}` + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := smartapi.NewRouterLogger(nil)
+			tt.api(r)
+
+			rr := httptest.NewRecorder()
+			r.MustHandler().ServeHTTP(rr, tt.request())
+
+			require.Equal(t, tt.responseCode, rr.Code)
+			require.Equal(t, tt.responseBody, rr.Body.String())
+		})
+	}
+}
+
+func TestQueryStructErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		api  func(r smartapi.Router)
+		err  string
+	}{
+		{
+			name: "Not a struct",
+			api: func(r smartapi.Router) {
+				r.Get("/test", func(*int) {}, smartapi.QueryStruct(12))
+			},
+			err: "endpoint /test: (argument 0) QueryStruct's argument must be a structure",
+		},
+		{
+			name: "Unsupported field",
+			api: func(r smartapi.Router) {
+				type query struct {
+					Values map[string]string `query:"values"`
+				}
+				r.Get("/test", func(*query) {}, smartapi.QueryStruct(query{}))
+			},
+			err: "endpoint /test: (argument 0) (struct field Values) cannot convert a string to map[string]string",
+		},
+		{
+			name: "Not a pointer",
+			api: func(r smartapi.Router) {
+				r.Get("/test", func(queryTestPage) {}, smartapi.QueryStruct(queryTestPage{}))
+			},
+			err: "endpoint /test: (argument 0) argument must be a pointer",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := smartapi.NewRouterLogger(nil)
+			tt.api(r)
+			_, err := r.Handler()
+			require.EqualError(t, err, tt.err)
+		})
+	}
+}
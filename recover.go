@@ -0,0 +1,94 @@
+package smartapi
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"runtime/debug"
+)
+
+// PanicPolicy selects how panics of handlers and arguments are handled
+type PanicPolicy int
+
+const (
+	// PanicRecover reports a panic to the Logger and results in 500 INTERNAL SERVER ERROR. This is the default.
+	PanicRecover PanicPolicy = iota
+	// PanicRepanic reports a panic to the Logger and panics again with the same value, which is useful in tests
+	PanicRepanic
+	// PanicPropagate leaves panics to the http server
+	PanicPropagate
+)
+
+// PanicRecovery sets how panics of handlers and arguments are handled
+func PanicRecovery(policy PanicPolicy) EndpointParam {
+	return configOption(func(c *endpointConfig) {
+		c.panicPolicy = policy
+	})
+}
+
+// panicWriter tracks if the response has been started, so a panic can still result in an error response
+type panicWriter struct {
+	http.ResponseWriter
+	written bool
+}
+
+func (p *panicWriter) WriteHeader(status int) {
+	p.written = true
+	p.ResponseWriter.WriteHeader(status)
+}
+
+func (p *panicWriter) Write(data []byte) (int, error) {
+	p.written = true
+	return p.ResponseWriter.Write(data)
+}
+
+func (p *panicWriter) Flush() {
+	p.written = true
+	flush(p.ResponseWriter)
+}
+
+func (p *panicWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := p.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer cannot be hijacked")
+	}
+	p.written = true
+	return hijacker.Hijack()
+}
+
+// recoverPanic handles a panic according to the endpoint's policy, it must be deferred.
+// The connection is aborted if the response has already been started.
+func recoverPanic(w http.ResponseWriter, r *http.Request, logger Logger) {
+	v := recover()
+	if v == nil {
+		return
+	}
+	if v == http.ErrAbortHandler {
+		panic(v)
+	}
+	logPanic(r, logger, v)
+	if configOf(r).panicPolicy == PanicRepanic {
+		panic(v)
+	}
+	if p, ok := w.(*panicWriter); ok && p.written {
+		panic(http.ErrAbortHandler)
+	}
+
+	w.Header().Del("Content-Length")
+	apiErr := Error(http.StatusInternalServerError, fmt.Sprintf("panic: %v", v), "internal server error")
+	writeError(w, r, apiErr, apiErr)
+}
+
+// logPanic reports a panic by the PanicLogger if the logger implements it or by LogError otherwise
+func logPanic(r *http.Request, logger Logger, v interface{}) {
+	if logger == nil {
+		return
+	}
+	if panicLogger, ok := logger.(PanicLogger); ok {
+		panicLogger.LogPanic(r.Context(), v, debug.Stack())
+		return
+	}
+	logger.LogError(r.Context(), fmt.Errorf("panic: %v\n%s", v, debug.Stack()))
+}
@@ -0,0 +1,230 @@
+package smartapi_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/mmbednarek/smartapi"
+	"github.com/mmbednarek/smartapi/mocks"
+	"github.com/mmbednarek/smartapi/smartapitest"
+	"github.com/stretchr/testify/require"
+)
+
+type panicArgument struct{}
+
+func (panicArgument) CheckArg(arg reflect.Type) error {
+	return nil
+}
+
+func (panicArgument) Value(w http.ResponseWriter, r *http.Request) (reflect.Value, error) {
+	panic("argument failure")
+}
+
+type panicTestLogger struct {
+	*mocks.MockLogger
+	*mocks.MockPanicLogger
+}
+
+func expectPanic(ctrl *gomock.Controller, t *testing.T, value interface{}) smartapi.Logger {
+	m := mocks.NewMockPanicLogger(ctrl)
+	m.EXPECT().LogPanic(gomock.Any(), value, gomock.Any()).Do(func(_ context.Context, _ interface{}, stack []byte) {
+		require.Contains(t, string(stack), "recover_test.go")
+	}).Times(1)
+	return panicTestLogger{MockLogger: mocks.NewMockLogger(ctrl), MockPanicLogger: m}
+}
+
+func expectPanicError(ctrl *gomock.Controller, t *testing.T, message string) smartapi.Logger {
+	m := mocks.NewMockLogger(ctrl)
+	m.EXPECT().LogError(gomock.Any(), gomock.Any()).Do(func(_ context.Context, err error) {
+		require.True(t, strings.HasPrefix(err.Error(), message+"\n"))
+		require.Contains(t, err.Error(), "recover_test.go")
+	}).Times(1)
+	return m
+}
+
+func TestPanicRecovery(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tests := []struct {
+		name         string
+		api          func(r smartapi.Router)
+		logger       smartapi.Logger
+		responseCode int
+		responseBody string
+		contentType  string
+		panics       interface{}
+	}{
+		{
+			name: "Handler panic",
+			api: func(r smartapi.Router) {
+				r.Get("/test", func() (string, error) {
+					panic("failure")
+				})
+			},
+			logger:       expectPanic(ctrl, t, "failure"),
+			responseCode: http.StatusInternalServerError,
+			responseBody: `{"status":500,"reason":"internal server error"}` + "\n",
+			contentType:  "application/json",
+		},
+		{
+			name: "Logger without LogPanic",
+			api: func(r smartapi.Router) {
+				r.Get("/test", func() (string, error) {
+					panic("failure")
+				})
+			},
+			logger:       expectPanicError(ctrl, t, "panic: failure"),
+			responseCode: http.StatusInternalServerError,
+			responseBody: `{"status":500,"reason":"internal server error"}` + "\n",
+			contentType:  "application/json",
+		},
+		{
+			name: "Argument panic",
+			api: func(r smartapi.Router) {
+				r.Get("/test", func(string) {}, smartapi.Custom(panicArgument{}, 0))
+			},
+			logger:       expectPanic(ctrl, t, "argument failure"),
+			responseCode: http.StatusInternalServerError,
+			responseBody: `{"status":500,"reason":"internal server error"}` + "\n",
+			contentType:  "application/json",
+		},
+		{
+			name: "Headers set before panic",
+			api: func(r smartapi.Router) {
+				r.Get("/test", func(headers smartapi.Headers) {
+					headers.Set("Content-Type", "text/csv")
+					headers.Set("Content-Length", "100")
+					panic("failure")
+				}, smartapi.ResponseHeaders())
+			},
+			logger:       expectPanic(ctrl, t, "failure"),
+			responseCode: http.StatusInternalServerError,
+			responseBody: `{"status":500,"reason":"internal server error"}` + "\n",
+			contentType:  "application/json",
+		},
+		{
+			name: "Problem details",
+			api: func(r smartapi.Router) {
+				r.Get("/test", func() {
+					panic("failure")
+				}, smartapi.ProblemDetails())
+			},
+			logger:       expectPanic(ctrl, t, "failure"),
+			responseCode: http.StatusInternalServerError,
+			responseBody: `{"status":500,"title":"internal server error","type":"about:blank"}` + "\n",
+			contentType:  "application/problem+json",
+		},
+		{
+			name: "Buffered response",
+			api: func(r smartapi.Router) {
+				r.Get("/test", func(w http.ResponseWriter) {
+					w.Header().Set("Content-Type", "text/plain")
+					_, _ = w.Write([]byte("partial"))
+					panic("failure")
+				}, smartapi.ResponseWriter(), smartapi.BufferedResponse())
+			},
+			logger:       expectPanic(ctrl, t, "failure"),
+			responseCode: http.StatusInternalServerError,
+			responseBody: `{"status":500,"reason":"internal server error"}` + "\n",
+			contentType:  "application/json",
+		},
+		{
+			name: "Response already written",
+			api: func(r smartapi.Router) {
+				r.Get("/test", func(w http.ResponseWriter) {
+					_, _ = w.Write([]byte("partial"))
+					panic("failure")
+				}, smartapi.ResponseWriter())
+			},
+			logger: expectPanic(ctrl, t, "failure"),
+			panics: http.ErrAbortHandler,
+		},
+		{
+			name: "Response flushed",
+			api: func(r smartapi.Router) {
+				r.Get("/test", func(w http.ResponseWriter) {
+					w.(http.Flusher).Flush()
+					panic("failure")
+				}, smartapi.ResponseWriter())
+			},
+			logger: expectPanic(ctrl, t, "failure"),
+			panics: http.ErrAbortHandler,
+		},
+		{
+			name: "Repanic",
+			api: func(r smartapi.Router) {
+				r.Defaults(smartapi.PanicRecovery(smartapi.PanicRepanic))
+				r.Get("/test", func() {
+					panic("failure")
+				})
+			},
+			logger: expectPanic(ctrl, t, "failure"),
+			panics: "failure",
+		},
+		{
+			name: "Propagate",
+			api: func(r smartapi.Router) {
+				r.Get("/test", func() {
+					panic("failure")
+				}, smartapi.PanicRecovery(smartapi.PanicPropagate))
+			},
+			logger: mocks.NewMockLogger(ctrl),
+			panics: "failure",
+		},
+		{
+			name: "Abort handler",
+			api: func(r smartapi.Router) {
+				r.Get("/test", func() {
+					panic(http.ErrAbortHandler)
+				})
+			},
+			logger: mocks.NewMockLogger(ctrl),
+			panics: http.ErrAbortHandler,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := smartapi.NewRouterLogger(tt.logger)
+			tt.api(r)
+
+			rr := httptest.NewRecorder()
+			req, err := http.NewRequest("GET", "/test", nil)
+			require.NoError(t, err)
+			if tt.panics != nil {
+				require.PanicsWithValue(t, tt.panics, func() {
+					r.MustHandler().ServeHTTP(rr, req)
+				})
+				return
+			}
+			r.MustHandler().ServeHTTP(rr, req)
+
+			require.Equal(t, tt.responseCode, rr.Code)
+			require.Equal(t, tt.responseBody, rr.Body.String())
+			require.Equal(t, tt.contentType, rr.Header().Get("Content-Type"))
+		})
+	}
+}
+
+func TestPanicRecoveryWebSocket(t *testing.T) {
+	r := smartapi.NewRouterLogger(nil)
+	r.WebSocket("/test", func(conn smartapi.Conn) {
+		var msg string
+		require.NoError(t, conn.Receive(&msg))
+		panic(msg)
+	})
+
+	conn, _, err := smartapitest.DialWebSocket(r.MustHandler(), "/test", nil)
+	require.NoError(t, err)
+	require.NoError(t, conn.Send("failure"))
+
+	var msg string
+	require.Equal(t, &smartapi.CloseError{Code: smartapi.CloseInternalError, Reason: "internal error"}, conn.Receive(&msg))
+	require.NoError(t, conn.Close(smartapi.CloseNormal, ""))
+}
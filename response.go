@@ -0,0 +1,193 @@
+package smartapi
+
+import (
+	"net/http"
+	"reflect"
+)
+
+// Response is returned by a handler to control the status, headers and cookies of the response.
+// Body is written the same way as a value returned by a handler, nil body results in no content.
+type Response struct {
+	Status  int
+	Headers http.Header
+	Cookies []*http.Cookie
+	Body    interface{}
+}
+
+var responseType = reflect.TypeOf(Response{})
+var responsePtrType = reflect.TypeOf(&Response{})
+var intType = reflect.TypeOf(0)
+
+// isResponseType checks if a type is Response or a pointer to Response
+func isResponseType(typ reflect.Type) bool {
+	return typ == responseType || typ == responsePtrType
+}
+
+// NilPolicy selects the response written when a handler returns a nil slice or map
+type NilPolicy int
+
+const (
+	// NilNoContent responds with 204 NO CONTENT. This is the default.
+	NilNoContent NilPolicy = iota
+	// NilEmpty responds with an empty collection, for example [] or {} in json
+	NilEmpty
+	// NilNull responds with a null value
+	NilNull
+)
+
+// NilResponse sets the response written when a handler returns a nil slice or map
+func NilResponse(policy NilPolicy) EndpointParam {
+	return configOption(func(c *endpointConfig) {
+		c.nilPolicy = policy
+	})
+}
+
+// nilResponse returns the value written instead of a nil response according to endpoint's nil policy.
+// False is returned if the response should have no content.
+func nilResponse(r *http.Request, value reflect.Value) (reflect.Value, bool) {
+	if value.Kind() != reflect.Slice && value.Kind() != reflect.Map {
+		return value, false
+	}
+	switch configOf(r).nilPolicy {
+	case NilEmpty:
+		if value.Kind() == reflect.Map {
+			return reflect.MakeMap(value.Type()), true
+		}
+		return reflect.MakeSlice(value.Type(), 0, 0), true
+	case NilNull:
+		return value, true
+	}
+	return value, false
+}
+
+// isResponseKind checks if values of a kind can be encoded as a response
+func isResponseKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Chan, reflect.Func, reflect.Complex64, reflect.Complex128, reflect.UnsafePointer, reflect.Invalid:
+		return false
+	}
+	return true
+}
+
+// writeValue writes a value returned by a handler with a status, zero status selects the default status.
+// Empty responses are written with emptyStatus by default, other responses with 200 OK.
+func writeValue(w http.ResponseWriter, r *http.Request, value reflect.Value, status int, emptyStatus int) error {
+	empty := !value.IsValid()
+	if !empty {
+		switch value.Kind() {
+		case reflect.Ptr, reflect.Interface, reflect.Slice, reflect.Map:
+			if value.IsNil() {
+				value, empty = nilResponse(r, value)
+				empty = !empty
+			}
+		}
+	}
+	if !empty && (value.Kind() == reflect.String || value.Type() == byteType) && value.Len() == 0 {
+		empty = true
+	}
+	if empty {
+		if status == 0 {
+			status = emptyStatus
+		}
+		w.WriteHeader(status)
+		return nil
+	}
+
+	if status == 0 {
+		status = http.StatusOK
+	}
+	switch {
+	case value.Kind() == reflect.String:
+		w.WriteHeader(status)
+		_, err := w.Write([]byte(value.String()))
+		return err
+	case value.Type() == byteType:
+		w.WriteHeader(status)
+		_, err := w.Write(value.Bytes())
+		return err
+	}
+
+	contentType, codec, err := responseCodec(r)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(status)
+	return codec.Encode(w, value.Interface())
+}
+
+// writeResponse writes a Response returned by a handler, nil response results in no content
+func writeResponse(w http.ResponseWriter, r *http.Request, response *Response, emptyStatus int) error {
+	if response == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}
+	for key, values := range response.Headers {
+		for _, value := range values {
+			w.Header().Add(key, value)
+		}
+	}
+	for _, cookie := range response.Cookies {
+		http.SetCookie(w, cookie)
+	}
+	return writeValue(w, r, reflect.ValueOf(response.Body), response.Status, emptyStatus)
+}
+
+type responseHandler struct {
+	handlerFunc interface{}
+	withError   bool
+	fields      *responseStruct
+}
+
+func (h responseHandler) handleRequest(w http.ResponseWriter, r *http.Request, logger Logger, endpoint endpointData) {
+	attribs, err := getCallAttributes(w, r, endpoint)
+	if err != nil {
+		handleError(w, r, logger, err)
+		return
+	}
+	value := reflect.ValueOf(h.handlerFunc)
+	result := value.Call(attribs)
+
+	if h.withError && !result[1].IsNil() {
+		handleErrorValue(w, r, logger, result[1])
+		return
+	}
+
+	var response *Response
+	switch resp := result[0].Interface().(type) {
+	case Response:
+		response = &resp
+	case *Response:
+		response = resp
+	default:
+		response = h.fields.response(result[0])
+	}
+	if err := writeResponse(w, r, response, endpoint.returnStatus); err != nil {
+		handleError(w, r, logger, err)
+		return
+	}
+}
+
+type statusHandler struct {
+	handlerFunc interface{}
+}
+
+func (h statusHandler) handleRequest(w http.ResponseWriter, r *http.Request, logger Logger, endpoint endpointData) {
+	attribs, err := getCallAttributes(w, r, endpoint)
+	if err != nil {
+		handleError(w, r, logger, err)
+		return
+	}
+	value := reflect.ValueOf(h.handlerFunc)
+	result := value.Call(attribs)
+
+	if !result[2].IsNil() {
+		handleErrorValue(w, r, logger, result[2])
+		return
+	}
+
+	if err := writeValue(w, r, result[0], int(result[1].Int()), http.StatusNoContent); err != nil {
+		handleError(w, r, logger, err)
+		return
+	}
+}
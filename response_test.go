@@ -0,0 +1,572 @@
+package smartapi_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mmbednarek/smartapi"
+	"github.com/stretchr/testify/require"
+)
+
+type responseTestStatus string
+
+func TestReturnKinds(t *testing.T) {
+	tests := []struct {
+		name         string
+		api          func(r smartapi.Router)
+		responseCode int
+		responseBody string
+	}{
+		{
+			name: "Map",
+			api: func(r smartapi.Router) {
+				r.Get("/test", func() map[string]int {
+					return map[string]int{"a": 1, "b": 2}
+				})
+			},
+			responseCode: http.StatusOK,
+			responseBody: `{"a":1,"b":2}` + "\n",
+		},
+		{
+			name: "Map with error",
+			api: func(r smartapi.Router) {
+				r.Get("/test", func() (map[string]string, error) {
+					return map[string]string{"a": "b"}, nil
+				})
+			},
+			responseCode: http.StatusOK,
+			responseBody: `{"a":"b"}` + "\n",
+		},
+		{
+			name: "Slice",
+			api: func(r smartapi.Router) {
+				r.Get("/test", func() []int {
+					return []int{1, 2, 3}
+				})
+			},
+			responseCode: http.StatusOK,
+			responseBody: `[1,2,3]` + "\n",
+		},
+		{
+			name: "Array with error",
+			api: func(r smartapi.Router) {
+				r.Get("/test", func() ([2]string, error) {
+					return [2]string{"a", "b"}, nil
+				})
+			},
+			responseCode: http.StatusOK,
+			responseBody: `["a","b"]` + "\n",
+		},
+		{
+			name: "Bool",
+			api: func(r smartapi.Router) {
+				r.Get("/test", func() bool {
+					return true
+				})
+			},
+			responseCode: http.StatusOK,
+			responseBody: `true` + "\n",
+		},
+		{
+			name: "Float with error",
+			api: func(r smartapi.Router) {
+				r.Get("/test", func() (float64, error) {
+					return 2.5, nil
+				})
+			},
+			responseCode: http.StatusOK,
+			responseBody: `2.5` + "\n",
+		},
+		{
+			name: "Int64",
+			api: func(r smartapi.Router) {
+				r.Get("/test", func() int64 {
+					return 1 << 40
+				})
+			},
+			responseCode: http.StatusOK,
+			responseBody: `1099511627776` + "\n",
+		},
+		{
+			name: "Uint8 with error",
+			api: func(r smartapi.Router) {
+				r.Get("/test", func() (uint8, error) {
+					return 0, errors.New("failed")
+				})
+			},
+			responseCode: http.StatusInternalServerError,
+			responseBody: `{"status":500,"reason":"unknown"}` + "\n",
+		},
+		{
+			name: "Named string",
+			api: func(r smartapi.Router) {
+				r.Get("/test", func() (responseTestStatus, error) {
+					return "active", nil
+				})
+			},
+			responseCode: http.StatusOK,
+			responseBody: `active`,
+		},
+		{
+			name: "Nil slice",
+			api: func(r smartapi.Router) {
+				r.Get("/test", func() []string {
+					return nil
+				})
+			},
+			responseCode: http.StatusNoContent,
+		},
+		{
+			name: "Nil map",
+			api: func(r smartapi.Router) {
+				r.Get("/test", func() (map[string]int, error) {
+					return nil, nil
+				})
+			},
+			responseCode: http.StatusNoContent,
+		},
+		{
+			name: "Empty slice",
+			api: func(r smartapi.Router) {
+				r.Get("/test", func() []string {
+					return []string{}
+				})
+			},
+			responseCode: http.StatusOK,
+			responseBody: `[]` + "\n",
+		},
+		{
+			name: "NilEmpty slice",
+			api: func(r smartapi.Router) {
+				r.Get("/test", func() ([]string, error) {
+					return nil, nil
+				}, smartapi.NilResponse(smartapi.NilEmpty))
+			},
+			responseCode: http.StatusOK,
+			responseBody: `[]` + "\n",
+		},
+		{
+			name: "NilEmpty map",
+			api: func(r smartapi.Router) {
+				r.Defaults(smartapi.NilResponse(smartapi.NilEmpty))
+				r.Get("/test", func() map[string]int {
+					return nil
+				})
+			},
+			responseCode: http.StatusOK,
+			responseBody: `{}` + "\n",
+		},
+		{
+			name: "NilNull",
+			api: func(r smartapi.Router) {
+				r.Get("/test", func() []int {
+					return nil
+				}, smartapi.NilResponse(smartapi.NilNull))
+			},
+			responseCode: http.StatusOK,
+			responseBody: `null` + "\n",
+		},
+		{
+			name: "NilEmpty doesn't change pointers",
+			api: func(r smartapi.Router) {
+				r.Get("/test", func() *struct{} {
+					return nil
+				}, smartapi.NilResponse(smartapi.NilEmpty))
+			},
+			responseCode: http.StatusNoContent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := smartapi.NewRouterLogger(nil)
+			tt.api(r)
+
+			rr := httptest.NewRecorder()
+			req, err := http.NewRequest("GET", "/test", nil)
+			require.NoError(t, err)
+			r.MustHandler().ServeHTTP(rr, req)
+
+			require.Equal(t, tt.responseCode, rr.Code)
+			require.Equal(t, tt.responseBody, rr.Body.String())
+		})
+	}
+}
+
+func TestReturnKindsErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		api  func(r smartapi.Router)
+		err  string
+	}{
+		{
+			name: "Complex",
+			api: func(r smartapi.Router) {
+				r.Get("/test", func() complex128 { return 0 })
+			},
+			err: "endpoint /test: unsupported return type",
+		},
+		{
+			name: "Func with error",
+			api: func(r smartapi.Router) {
+				r.Get("/test", func() (func(), error) { return nil, nil })
+			},
+			err: "endpoint /test: unsupported return type",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := smartapi.NewRouterLogger(nil)
+			tt.api(r)
+			_, err := r.Handler()
+			require.EqualError(t, err, tt.err)
+		})
+	}
+}
+
+func TestResponse(t *testing.T) {
+	tests := []struct {
+		name         string
+		api          func(r smartapi.Router)
+		responseCode int
+		responseBody string
+		header       http.Header
+	}{
+		{
+			name: "Response pointer",
+			api: func(r smartapi.Router) {
+				r.Put("/test", func() (*smartapi.Response, error) {
+					return &smartapi.Response{
+						Status:  http.StatusCreated,
+						Headers: http.Header{"Location": {"/users/1"}},
+						Cookies: []*http.Cookie{{Name: "session", Value: "abc"}},
+						Body:    map[string]int{"id": 1},
+					}, nil
+				})
+			},
+			responseCode: http.StatusCreated,
+			responseBody: `{"id":1}` + "\n",
+			header: http.Header{
+				"Location":     {"/users/1"},
+				"Set-Cookie":   {"session=abc"},
+				"Content-Type": {"application/json"},
+			},
+		},
+		{
+			name: "Response value",
+			api: func(r smartapi.Router) {
+				r.Put("/test", func() smartapi.Response {
+					return smartapi.Response{Body: "updated"}
+				})
+			},
+			responseCode: http.StatusOK,
+			responseBody: "updated",
+		},
+		{
+			name: "Response without body",
+			api: func(r smartapi.Router) {
+				r.Put("/test", func() (smartapi.Response, error) {
+					return smartapi.Response{Headers: http.Header{"X-Id": {"1"}}}, nil
+				}, smartapi.ResponseStatus(http.StatusAccepted))
+			},
+			responseCode: http.StatusAccepted,
+			header:       http.Header{"X-Id": {"1"}},
+		},
+		{
+			name: "Nil response",
+			api: func(r smartapi.Router) {
+				r.Put("/test", func() (*smartapi.Response, error) {
+					return nil, nil
+				})
+			},
+			responseCode: http.StatusNoContent,
+		},
+		{
+			name: "Response error",
+			api: func(r smartapi.Router) {
+				r.Put("/test", func() (*smartapi.Response, error) {
+					return nil, smartapi.Error(http.StatusConflict, "conflict", "conflict")
+				})
+			},
+			responseCode: http.StatusConflict,
+			responseBody: `{"status":409,"reason":"conflict"}` + "\n",
+		},
+		{
+			name: "Status triple",
+			api: func(r smartapi.Router) {
+				r.Put("/test", func() (*struct {
+					ID int `json:"id"`
+				}, int, error) {
+					return &struct {
+						ID int `json:"id"`
+					}{ID: 1}, http.StatusCreated, nil
+				})
+			},
+			responseCode: http.StatusCreated,
+			responseBody: `{"id":1}` + "\n",
+		},
+		{
+			name: "Status triple default status",
+			api: func(r smartapi.Router) {
+				r.Put("/test", func() (string, int, error) {
+					return "updated", 0, nil
+				})
+			},
+			responseCode: http.StatusOK,
+			responseBody: "updated",
+		},
+		{
+			name: "Status triple empty",
+			api: func(r smartapi.Router) {
+				r.Put("/test", func() ([]int, int, error) {
+					return nil, http.StatusAccepted, nil
+				})
+			},
+			responseCode: http.StatusAccepted,
+		},
+		{
+			name: "Status triple error",
+			api: func(r smartapi.Router) {
+				r.Put("/test", func() (string, int, error) {
+					return "", 0, smartapi.Error(http.StatusNotFound, "not found", "not found")
+				})
+			},
+			responseCode: http.StatusNotFound,
+			responseBody: `{"status":404,"reason":"not found"}` + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := smartapi.NewRouterLogger(nil)
+			tt.api(r)
+
+			rr := httptest.NewRecorder()
+			req, err := http.NewRequest("PUT", "/test", nil)
+			require.NoError(t, err)
+			r.MustHandler().ServeHTTP(rr, req)
+
+			require.Equal(t, tt.responseCode, rr.Code)
+			require.Equal(t, tt.responseBody, rr.Body.String())
+			for key, value := range tt.header {
+				require.Equal(t, value, rr.Header()[key])
+			}
+		})
+	}
+}
+
+func TestResponseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		api  func(r smartapi.Router)
+		err  string
+	}{
+		{
+			name: "Response and ResponseWriter",
+			api: func(r smartapi.Router) {
+				r.Get("/test", func(http.ResponseWriter) *smartapi.Response { return nil }, smartapi.ResponseWriter())
+			},
+			err: "endpoint /test: cannot write response and return response",
+		},
+		{
+			name: "Status is not an int",
+			api: func(r smartapi.Router) {
+				r.Get("/test", func() (string, int64, error) { return "", 0, nil })
+			},
+			err: "endpoint /test: expect an int status in return arguments",
+		},
+		{
+			name: "No error in triple",
+			api: func(r smartapi.Router) {
+				r.Get("/test", func() (string, int, string) { return "", 0, "" })
+			},
+			err: "endpoint /test: expect an error type in return arguments",
+		},
+		{
+			name: "Response in triple",
+			api: func(r smartapi.Router) {
+				r.Get("/test", func() (*smartapi.Response, int, error) { return nil, 0, nil })
+			},
+			err: "endpoint /test: unsupported return type",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := smartapi.NewRouterLogger(nil)
+			tt.api(r)
+			_, err := r.Handler()
+			require.EqualError(t, err, tt.err)
+		})
+	}
+}
+
+type responseTestUsers struct {
+	Total    int          `smartapi:"response_header=X-Total-Count"`
+	Links    []string     `smartapi:"response_header=Link"`
+	Next     *string      `smartapi:"response_header=X-Next"`
+	Session  string       `smartapi:"response_cookie=session"`
+	Tracking *http.Cookie `smartapi:"response_cookie=tracking"`
+	Status   int          `smartapi:"status"`
+	Users    []string     `smartapi:"body"`
+	Ignored  string
+}
+
+func TestResponseStruct(t *testing.T) {
+	tests := []struct {
+		name         string
+		api          func(r smartapi.Router)
+		responseCode int
+		responseBody string
+		header       http.Header
+	}{
+		{
+			name: "Response struct",
+			api: func(r smartapi.Router) {
+				r.Get("/test", func() (responseTestUsers, error) {
+					return responseTestUsers{
+						Total:    12,
+						Links:    []string{"</users?page=2>; rel=next", "</users?page=6>; rel=last"},
+						Session:  "abc",
+						Tracking: &http.Cookie{Value: "xyz", Path: "/"},
+						Status:   http.StatusPartialContent,
+						Users:    []string{"John", "Bob"},
+					}, nil
+				})
+			},
+			responseCode: http.StatusPartialContent,
+			responseBody: `["John","Bob"]` + "\n",
+			header: http.Header{
+				"X-Total-Count": {"12"},
+				"Link":          {"</users?page=2>; rel=next", "</users?page=6>; rel=last"},
+				"Set-Cookie":    {"session=abc", "tracking=xyz; Path=/"},
+			},
+		},
+		{
+			name: "Pointer without status",
+			api: func(r smartapi.Router) {
+				r.Get("/test", func() *struct {
+					Location string `smartapi:"response_header=Location"`
+					Body     struct {
+						ID int `json:"id"`
+					} `smartapi:"body"`
+				} {
+					result := &struct {
+						Location string `smartapi:"response_header=Location"`
+						Body     struct {
+							ID int `json:"id"`
+						} `smartapi:"body"`
+					}{Location: "/users/1"}
+					result.Body.ID = 1
+					return result
+				})
+			},
+			responseCode: http.StatusOK,
+			responseBody: `{"id":1}` + "\n",
+			header:       http.Header{"Location": {"/users/1"}},
+		},
+		{
+			name: "Without body",
+			api: func(r smartapi.Router) {
+				r.Get("/test", func() (struct {
+					Location string `smartapi:"response_header=Location"`
+				}, error) {
+					return struct {
+						Location string `smartapi:"response_header=Location"`
+					}{Location: "/users/1"}, nil
+				}, smartapi.ResponseStatus(http.StatusCreated))
+			},
+			responseCode: http.StatusCreated,
+			header:       http.Header{"Location": {"/users/1"}},
+		},
+		{
+			name: "Nil pointer",
+			api: func(r smartapi.Router) {
+				r.Get("/test", func() (*responseTestUsers, error) {
+					return nil, nil
+				})
+			},
+			responseCode: http.StatusNoContent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := smartapi.NewRouterLogger(nil)
+			tt.api(r)
+
+			rr := httptest.NewRecorder()
+			req, err := http.NewRequest("GET", "/test", nil)
+			require.NoError(t, err)
+			r.MustHandler().ServeHTTP(rr, req)
+
+			require.Equal(t, tt.responseCode, rr.Code)
+			require.Equal(t, tt.responseBody, rr.Body.String())
+			for key, value := range tt.header {
+				require.Equal(t, value, rr.Header()[key])
+			}
+		})
+	}
+}
+
+func TestResponseStructErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		api  func(r smartapi.Router)
+		err  string
+	}{
+		{
+			name: "Unsupported tag",
+			api: func(r smartapi.Router) {
+				type response struct {
+					Name string `smartapi:"header=name"`
+				}
+				r.Get("/test", func() response { return response{} })
+			},
+			err: "endpoint /test: (struct field Name) unsupported response tag header",
+		},
+		{
+			name: "Invalid header",
+			api: func(r smartapi.Router) {
+				type response struct {
+					Values map[string]string `smartapi:"response_header=X-Values"`
+				}
+				r.Get("/test", func() (*response, error) { return nil, nil })
+			},
+			err: "endpoint /test: (struct field Values) cannot write map[string]string as a header",
+		},
+		{
+			name: "Invalid status",
+			api: func(r smartapi.Router) {
+				type response struct {
+					Status string `smartapi:"status"`
+				}
+				r.Get("/test", func() response { return response{} })
+			},
+			err: "endpoint /test: (struct field Status) status must be an int",
+		},
+		{
+			name: "Two bodies",
+			api: func(r smartapi.Router) {
+				type response struct {
+					A string `smartapi:"body"`
+					B string `smartapi:"body"`
+				}
+				r.Get("/test", func() response { return response{} })
+			},
+			err: "endpoint /test: only one struct field can be the response body",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := smartapi.NewRouterLogger(nil)
+			tt.api(r)
+			_, err := r.Handler()
+			require.EqualError(t, err, tt.err)
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package smartapi
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+var cookieType = reflect.TypeOf(http.Cookie{})
+var cookiePtrType = reflect.TypeOf(&http.Cookie{})
+
+// responseField is a field of a response struct written as a header or a cookie
+type responseField struct {
+	index int
+	name  string
+}
+
+// responseStruct describes a struct returned by a handler with fields written to the response by smartapi tags
+type responseStruct struct {
+	structType reflect.Type
+	headers    []responseField
+	cookies    []responseField
+	status     int
+	body       int
+}
+
+// parseResponseStruct parses smartapi tags of a struct returned by a handler.
+// False is returned if the type is not a struct or a pointer to a struct with smartapi tags.
+func parseResponseStruct(typ reflect.Type) (*responseStruct, bool, error) {
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct {
+		return nil, false, nil
+	}
+
+	result := &responseStruct{structType: typ, status: -1, body: -1}
+	tagged := false
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		tag, ok := f.Tag.Lookup(smartAPITagName)
+		if !ok {
+			continue
+		}
+		tagged = true
+		if len(f.PkgPath) != 0 {
+			return nil, true, fmt.Errorf("(struct field %s) tagged field must be exported", f.Name)
+		}
+
+		kind, name := tag, ""
+		if eqAt := strings.Index(tag, "="); eqAt >= 0 {
+			kind, name = tag[:eqAt], tag[eqAt+1:]
+		}
+		switch kind {
+		case "response_header":
+			if err := checkListArg(f.Type); err != nil {
+				return nil, true, fmt.Errorf("(struct field %s) cannot write %s as a header", f.Name, f.Type)
+			}
+			result.headers = append(result.headers, responseField{index: i, name: name})
+		case "response_cookie":
+			if f.Type != cookieType && f.Type != cookiePtrType && checkStringArg(f.Type) != nil {
+				return nil, true, fmt.Errorf("(struct field %s) cannot write %s as a cookie", f.Name, f.Type)
+			}
+			result.cookies = append(result.cookies, responseField{index: i, name: name})
+		case "status":
+			if f.Type.Kind() != reflect.Int {
+				return nil, true, fmt.Errorf("(struct field %s) status must be an int", f.Name)
+			}
+			if result.status >= 0 {
+				return nil, true, errors.New("only one struct field can be the response status")
+			}
+			result.status = i
+		case "body":
+			if !isResponseKind(f.Type.Kind()) {
+				return nil, true, fmt.Errorf("(struct field %s) unsupported body type %s", f.Name, f.Type)
+			}
+			if result.body >= 0 {
+				return nil, true, errors.New("only one struct field can be the response body")
+			}
+			result.body = i
+		default:
+			return nil, true, fmt.Errorf("(struct field %s) unsupported response tag %s", f.Name, kind)
+		}
+	}
+	if !tagged {
+		return nil, false, nil
+	}
+	return result, true, nil
+}
+
+// response converts a value of the struct into a Response, nil pointer results in nil response
+func (s *responseStruct) response(v reflect.Value) *Response {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	response := &Response{Headers: http.Header{}}
+	for _, h := range s.headers {
+		field := v.Field(h.index)
+		if field.Kind() == reflect.Ptr {
+			if field.IsNil() {
+				continue
+			}
+			field = field.Elem()
+		}
+		if field.Kind() == reflect.Slice && field.Type() != byteType {
+			for i := 0; i < field.Len(); i++ {
+				response.Headers.Add(h.name, formatParam(field.Index(i)))
+			}
+			continue
+		}
+		response.Headers.Add(h.name, formatParam(field))
+	}
+	for _, c := range s.cookies {
+		if cookie := fieldCookie(v.Field(c.index), c.name); cookie != nil {
+			response.Cookies = append(response.Cookies, cookie)
+		}
+	}
+	if s.status >= 0 {
+		response.Status = int(v.Field(s.status).Int())
+	}
+	if s.body >= 0 {
+		response.Body = v.Field(s.body).Interface()
+	}
+	return response
+}
+
+// fieldCookie returns a cookie of a field, nil is returned for nil pointers
+func fieldCookie(field reflect.Value, name string) *http.Cookie {
+	switch field.Type() {
+	case cookieType:
+		cookie := field.Interface().(http.Cookie)
+		field = reflect.ValueOf(&cookie)
+		fallthrough
+	case cookiePtrType:
+		if field.IsNil() {
+			return nil
+		}
+		cookie := *field.Interface().(*http.Cookie)
+		if len(cookie.Name) == 0 {
+			cookie.Name = name
+		}
+		return &cookie
+	}
+	if field.Kind() == reflect.Ptr {
+		if field.IsNil() {
+			return nil
+		}
+		field = field.Elem()
+	}
+	return &http.Cookie{Name: name, Value: formatParam(field)}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	"reflect"
+	"strings"
 
 	"github.com/go-chi/chi"
 )
@@ -22,10 +23,19 @@ type Router interface {
 	Options(pattern string, handler interface{}, args ...EndpointParam)
 	Connect(pattern string, handler interface{}, args ...EndpointParam)
 	Trace(pattern string, handler interface{}, args ...EndpointParam)
+	Events(pattern string, handler interface{}, args ...EndpointParam)
+	WebSocket(pattern string, handler interface{}, args ...EndpointParam)
 	Route(pattern string, handler RouteHandler, args ...EndpointParam)
+	Defaults(params ...EndpointParam)
+	MapError(target error, status int, reason string)
+	MapErrorAs(target interface{}, status int, reason string)
+	MapErrorFunc(match func(err error) bool, status int, reason string)
 	Handle(pattern string, handler http.Handler)
 	Handler() (http.Handler, error)
 	MustHandler() http.Handler
+	Routes() []RouteInfo
+	OpenAPI(info OpenAPIInfo) *OpenAPIDocument
+	OpenAPIHandler(info OpenAPIInfo) http.Handler
 }
 
 type RouteHandler func(r Router)
@@ -35,26 +45,49 @@ type router struct {
 	errors    []error
 	logger    Logger
 	params    []EndpointParam
+	prefix    string
+	state     *routerState
 }
 
-func NewRouter() *router {
-	return &router{
-		chiRouter: chi.NewRouter(),
-		logger:    DefaultLogger,
-	}
+// routerState is shared by a router and all routers derived from it with Route or With
+type routerState struct {
+	endpoints     []endpointInfo
+	defaults      []endpointOption
+	errorMappings []errorMapping
 }
 
-func NewRouterLogger(logger Logger) *router {
-	return &router{
+// endpointInfo describes a registered endpoint
+type endpointInfo struct {
+	method       Method
+	pattern      string
+	handler      interface{}
+	arguments    []Argument
+	returnStatus int
+}
+
+func newRouter(logger Logger) router {
+	return router{
 		chiRouter: chi.NewRouter(),
 		logger:    logger,
+		state:     &routerState{},
 	}
 }
 
+func NewRouter() *router {
+	r := newRouter(DefaultLogger)
+	return &r
+}
+
+func NewRouterLogger(logger Logger) *router {
+	r := newRouter(logger)
+	return &r
+}
+
 var errType = reflect.TypeOf((*error)(nil)).Elem()
 var byteType = reflect.TypeOf([]byte(nil))
 
-func checkHandler(handlerFunc interface{}, arguments []Argument, writesResponse bool) (endpointHandler, error) {
+// checkArguments checks if arguments of a handler match endpoint's arguments
+func checkArguments(handlerFunc interface{}, arguments []Argument) (reflect.Type, error) {
 	fnType := reflect.TypeOf(handlerFunc)
 	if fnType.Kind() != reflect.Func {
 		return nil, errors.New("handler must be a function")
@@ -69,6 +102,15 @@ func checkHandler(handlerFunc interface{}, arguments []Argument, writesResponse
 		if err := arguments[i].checkArg(arg); err != nil {
 			return nil, fmt.Errorf("(argument %d) %w", i, err)
 		}
+		arguments[i] = bindArgument(arguments[i], arg)
+	}
+	return fnType, nil
+}
+
+func checkHandler(handlerFunc interface{}, arguments []Argument, writesResponse bool) (endpointHandler, error) {
+	fnType, err := checkArguments(handlerFunc, arguments)
+	if err != nil {
+		return nil, err
 	}
 
 	switch fnType.NumOut() {
@@ -81,6 +123,28 @@ func checkHandler(handlerFunc interface{}, arguments []Argument, writesResponse
 		}
 
 		value := fnType.Out(0)
+		fields, isStruct, err := parseResponseStruct(value)
+		if err != nil {
+			return nil, err
+		}
+		if isResponseType(value) || isStruct {
+			if writesResponse {
+				return nil, errors.New("cannot write response and return response")
+			}
+			return responseHandler{handlerFunc: handlerFunc, fields: fields}, nil
+		}
+		if isFileType(value) {
+			if writesResponse {
+				return nil, errors.New("cannot write response and return response")
+			}
+			return fileHandler{handlerFunc: handlerFunc}, nil
+		}
+		if isReaderType(value) || isStreamType(value) {
+			if writesResponse {
+				return nil, errors.New("cannot write response and return response")
+			}
+			return streamHandler{handlerFunc: handlerFunc}, nil
+		}
 		switch value.Kind() {
 		case reflect.String:
 			return stringHandler{handlerFunc: handlerFunc}, nil
@@ -89,9 +153,10 @@ func checkHandler(handlerFunc interface{}, arguments []Argument, writesResponse
 				return byteSliceHandler{handlerFunc: handlerFunc}, nil
 			}
 			fallthrough
-		case reflect.Ptr, reflect.Interface:
+		case reflect.Ptr, reflect.Interface, reflect.Map:
 			return ptrHandler{handlerFunc: handlerFunc}, nil
-		case reflect.Struct, reflect.Int:
+		}
+		if isResponseKind(value.Kind()) {
 			return structHandler{handlerFunc: handlerFunc}, nil
 		}
 
@@ -107,6 +172,19 @@ func checkHandler(handlerFunc interface{}, arguments []Argument, writesResponse
 		}
 
 		value := fnType.Out(0)
+		fields, isStruct, err := parseResponseStruct(value)
+		if err != nil {
+			return nil, err
+		}
+		if isResponseType(value) || isStruct {
+			return responseHandler{handlerFunc: handlerFunc, withError: true, fields: fields}, nil
+		}
+		if isFileType(value) {
+			return fileHandler{handlerFunc: handlerFunc, withError: true}, nil
+		}
+		if isReaderType(value) || isStreamType(value) {
+			return streamHandler{handlerFunc: handlerFunc, withError: true}, nil
+		}
 		switch value.Kind() {
 		case reflect.String:
 			return stringErrorHandler{handlerFunc: handlerFunc}, nil
@@ -115,13 +193,29 @@ func checkHandler(handlerFunc interface{}, arguments []Argument, writesResponse
 				return byteSliceErrorHandler{handlerFunc: handlerFunc}, nil
 			}
 			fallthrough
-		case reflect.Ptr, reflect.Interface:
+		case reflect.Ptr, reflect.Interface, reflect.Map:
 			return ptrErrorHandler{handlerFunc: handlerFunc}, nil
-		case reflect.Struct, reflect.Int:
+		}
+		if isResponseKind(value.Kind()) {
 			return structErrorHandler{handlerFunc: handlerFunc}, nil
 		}
 
 		return nil, errors.New("unsupported return type")
+	case 3:
+		if writesResponse {
+			return nil, errors.New("cannot write response and return response")
+		}
+		if fnType.Out(1) != intType {
+			return nil, errors.New("expect an int status in return arguments")
+		}
+		if !fnType.Out(2).Implements(errType) {
+			return nil, errors.New("expect an error type in return arguments")
+		}
+		value := fnType.Out(0)
+		if isResponseType(value) || isReaderType(value) || isFileType(value) || !isResponseKind(value.Kind()) {
+			return nil, errors.New("unsupported return type")
+		}
+		return statusHandler{handlerFunc: handlerFunc}, nil
 	}
 	return nil, errors.New("invalid number of return arguments")
 }
@@ -167,24 +261,34 @@ func (r *router) AddEndpoint(method Method, name string, handler interface{}, pa
 	returnStatus := 0
 	query := false
 	writesResponse := false
-	numReadsBody := 0
+	events := false
+	webSocket := false
+	var readsBody bodyReaders
 
 	joinedParams := append(r.params, params...)
 	var args []Argument
+	var options []endpointOption
 	for i, a := range joinedParams {
 		flags := a.options()
 		if flags.has(flagArgument) {
 			args = append(args, a.(Argument))
 		}
+		if flags.has(flagEndpointOption) {
+			options = append(options, a.(endpointOption))
+		}
 		if flags.has(flagParsesQuery) {
 			query = true
 		}
+		if flags.has(flagEventStream) {
+			events = true
+		}
+		if flags.has(flagWebSocket) {
+			webSocket = true
+		}
 		if flags.has(flagResponseStatus) {
 			returnStatus = a.(responseStatusArgument).status
 		}
-		if flags.has(flagReadsRequestBody) {
-			numReadsBody++
-		}
+		readsBody.add(flags)
 		if flags.has(flagWritesResponse) {
 			writesResponse = true
 			if returnStatus == 0 {
@@ -199,18 +303,29 @@ func (r *router) AddEndpoint(method Method, name string, handler interface{}, pa
 
 	if h, ok := isLegacyHandler(returnStatus, args, handler); ok {
 		r.chiRouter.MethodFunc(method.String(), name, h)
+		r.addEndpointInfo(method, name, handler, args, returnStatus)
 		return
 	}
 
+	if webSocket {
+		returnStatus = http.StatusSwitchingProtocols
+	}
 	if returnStatus == 0 {
 		returnStatus = http.StatusNoContent
 	}
 
-	if numReadsBody > 1 {
+	if readsBody.count > 1 {
 		r.errors = append(r.errors, fmt.Errorf("endpoint %s: only one argument can read request's body", name))
 	}
 
-	endpointHandler, err := checkHandler(handler, args, writesResponse)
+	check := checkHandler
+	if events {
+		check = checkEventsHandler
+	}
+	if webSocket {
+		check = checkWebSocketHandler
+	}
+	endpointHandler, err := check(handler, args, writesResponse)
 	if err != nil {
 		r.errors = append(r.errors, fmt.Errorf("endpoint %s: %w", name, err))
 	}
@@ -223,13 +338,59 @@ func (r *router) AddEndpoint(method Method, name string, handler interface{}, pa
 		arguments:    args,
 		returnStatus: returnStatus,
 		query:        query,
+		multipart:    readsBody.multipartForm,
+		options:      options,
+		state:        r.state,
 	}
 
+	streams := false
+	switch endpointHandler.(type) {
+	case streamHandler, eventsHandler, webSocketHandler, fileHandler:
+		streams = true
+	}
 	f := func(w http.ResponseWriter, rq *http.Request) {
-		endpointHandler.handleRequest(w, rq, r.logger, data)
+		rq = withEndpointConfig(rq, data.config())
+		limitBody(w, rq)
+		if data.multipart {
+			var cleanup func()
+			rq, cleanup = withMultipartCleanup(rq)
+			defer cleanup()
+		}
+		serve := func(w http.ResponseWriter) {
+			if configOf(rq).panicPolicy != PanicPropagate {
+				if _, buffered := w.(*bufferedResponseWriter); !buffered {
+					w = &panicWriter{ResponseWriter: w}
+				}
+				defer recoverPanic(w, rq, r.logger)
+			}
+			endpointHandler.handleRequest(w, rq, r.logger, data)
+		}
+		if configOf(rq).bufferedResponse && !streams {
+			serveBuffered(w, rq, r.logger, serve)
+			return
+		}
+		serve(w)
 	}
 
 	r.chiRouter.MethodFunc(method.String(), name, f)
+	r.addEndpointInfo(method, name, handler, args, returnStatus)
+}
+
+func (r *router) addEndpointInfo(method Method, pattern string, handler interface{}, args []Argument, returnStatus int) {
+	r.state.endpoints = append(r.state.endpoints, endpointInfo{
+		method:       method,
+		pattern:      joinPattern(r.prefix, pattern),
+		handler:      handler,
+		arguments:    args,
+		returnStatus: returnStatus,
+	})
+}
+
+func joinPattern(prefix, pattern string) string {
+	if len(prefix) == 0 {
+		return pattern
+	}
+	return strings.TrimSuffix(prefix, "/") + pattern
 }
 
 // Use adds chi middlewares
@@ -244,6 +405,8 @@ func (r *router) With(middlewares ...func(http.Handler) http.Handler) Router {
 		errors:    r.errors,
 		logger:    r.logger,
 		params:    r.params,
+		prefix:    r.prefix,
+		state:     r.state,
 	}
 }
 
@@ -292,6 +455,18 @@ func (r *router) Trace(pattern string, handler interface{}, args ...EndpointPara
 	r.AddEndpoint(MethodTrace, pattern, handler, args)
 }
 
+// Events adds a GET endpoint streaming server-sent events received from a channel returned by the handler
+func (r *router) Events(pattern string, handler interface{}, args ...EndpointParam) {
+	r.AddEndpoint(MethodGet, pattern, handler, append([]EndpointParam{eventStreamParam{}}, args...))
+}
+
+// WebSocket adds a GET endpoint upgrading the connection to a websocket.
+// Handler's arguments are resolved before the upgrade, the connection is passed as the last argument.
+func (r *router) WebSocket(pattern string, handler interface{}, args ...EndpointParam) {
+	params := append([]EndpointParam{webSocketParam{}}, args...)
+	r.AddEndpoint(MethodGet, pattern, handler, append(params, webSocketConnArgument{}))
+}
+
 // Route routs endpoints to a specific path
 func (r *router) Route(pattern string, handler RouteHandler, params ...EndpointParam) {
 	if handler == nil {
@@ -303,6 +478,8 @@ func (r *router) Route(pattern string, handler RouteHandler, params ...EndpointP
 			logger:    r.logger,
 			chiRouter: rt,
 			params:    append(r.params, params...),
+			prefix:    joinPattern(r.prefix, pattern),
+			state:     r.state,
 		}
 		handler(node)
 		for _, err := range node.errors {
@@ -311,6 +488,67 @@ func (r *router) Route(pattern string, handler RouteHandler, params ...EndpointP
 	})
 }
 
+// Defaults sets endpoint options used by all endpoints of the router.
+// Options passed to routes and endpoints take precedence over the defaults.
+func (r *router) Defaults(params ...EndpointParam) {
+	for i, p := range params {
+		flags := p.options()
+		if flags.has(flagError) {
+			r.errors = append(r.errors, fmt.Errorf("defaults: (param %d) %w", i, p.(errorEndpointParam).err))
+			continue
+		}
+		if !flags.has(flagEndpointOption) {
+			r.errors = append(r.errors, fmt.Errorf("defaults: (param %d) only endpoint options can be used as defaults", i))
+			continue
+		}
+		r.state.defaults = append(r.state.defaults, p.(endpointOption))
+	}
+}
+
+// MapError makes errors matching the target with errors.Is to be returned with a status code and a reason.
+// Mappings are shared by all endpoints of the router and are checked in the order of registration.
+func (r *router) MapError(target error, status int, reason string) {
+	if target == nil {
+		r.errors = append(r.errors, errors.New("map error: nil target"))
+		return
+	}
+	r.MapErrorFunc(func(err error) bool {
+		return errors.Is(err, target)
+	}, status, reason)
+}
+
+// MapErrorAs makes errors matching the target with errors.As to be returned with a status code and a reason.
+// Like the second argument of errors.As, target must be a pointer to a type implementing error or to an interface,
+// for example new(*MyError).
+func (r *router) MapErrorAs(target interface{}, status int, reason string) {
+	targetType := reflect.TypeOf(target)
+	if targetType == nil || targetType.Kind() != reflect.Ptr {
+		r.errors = append(r.errors, errors.New("map error: target must be a pointer"))
+		return
+	}
+	elemType := targetType.Elem()
+	if elemType.Kind() != reflect.Interface && !elemType.Implements(errType) {
+		r.errors = append(r.errors, fmt.Errorf("map error: %s does not implement error", elemType))
+		return
+	}
+	r.MapErrorFunc(func(err error) bool {
+		return errors.As(err, reflect.New(elemType).Interface())
+	}, status, reason)
+}
+
+// MapErrorFunc makes errors matched by a predicate to be returned with a status code and a reason
+func (r *router) MapErrorFunc(match func(err error) bool, status int, reason string) {
+	if match == nil {
+		r.errors = append(r.errors, errors.New("map error: nil predicate"))
+		return
+	}
+	r.state.errorMappings = append(r.state.errorMappings, errorMapping{
+		match:  match,
+		status: status,
+		reason: reason,
+	})
+}
+
 // Handle handles request with specified http handler
 func (r *router) Handle(pattern string, handler http.Handler) {
 	r.chiRouter.Handle(pattern, handler)
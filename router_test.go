@@ -1,6 +1,8 @@
 package smartapi
 
 import (
+	"errors"
+	"net/http"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -22,3 +24,17 @@ func Test_router_MustHandler(t *testing.T) {
 		r.MustHandler()
 	})
 }
+
+func Test_router_MapErrorErrors(t *testing.T) {
+	r := NewRouter()
+	r.MapError(nil, http.StatusNotFound, "not found")
+	r.MapErrorAs(errors.New("test"), http.StatusNotFound, "not found")
+	r.MapErrorAs(nil, http.StatusNotFound, "not found")
+	r.MapErrorAs(new(int), http.StatusNotFound, "not found")
+	r.MapErrorFunc(nil, http.StatusNotFound, "not found")
+	r.MapErrorAs(new(error), http.StatusNotFound, "not found")
+
+	_, err := r.Handler()
+	require.EqualError(t, err, "map error: nil target, map error: errors.errorString does not implement error, "+
+		"map error: target must be a pointer, map error: int does not implement error, map error: nil predicate")
+}
@@ -0,0 +1,107 @@
+package smartapi
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"runtime"
+	"strings"
+	"text/tabwriter"
+)
+
+// RouteInfo describes a registered endpoint
+type RouteInfo struct {
+	Method       Method
+	Pattern      string
+	Arguments    []ArgumentInfo
+	ReturnStatus int
+	HandlerName  string
+}
+
+// ArgumentInfo describes an argument passed to a handler.
+// Kind is equal to the argument's tag name used in request structs.
+type ArgumentInfo struct {
+	Kind     string
+	Name     string
+	Required bool
+	Type     reflect.Type
+	Fields   []ArgumentInfo
+}
+
+// String formats an argument the way it would be written in a request struct tag followed by its type
+func (a ArgumentInfo) String() string {
+	result := a.Kind
+	if len(a.Name) != 0 {
+		result += "=" + a.Name
+	}
+	if len(a.Fields) != 0 {
+		fields := make([]string, len(a.Fields))
+		for i, f := range a.Fields {
+			fields[i] = f.String()
+		}
+		result += "{" + strings.Join(fields, ", ") + "}"
+	}
+	if a.Type != nil {
+		result += " " + a.Type.String()
+	}
+	return result
+}
+
+// Routes returns descriptions of all endpoints registered in the router in the order of registration
+func (r *router) Routes() []RouteInfo {
+	routes := make([]RouteInfo, 0, len(r.state.endpoints))
+	for _, e := range r.state.endpoints {
+		routes = append(routes, RouteInfo{
+			Method:       e.method,
+			Pattern:      e.pattern,
+			Arguments:    exportArguments(describeArguments(e.handler, e.arguments)),
+			ReturnStatus: e.returnStatus,
+			HandlerName:  handlerName(e.handler),
+		})
+	}
+	return routes
+}
+
+func exportArguments(infos []argumentInfo) []ArgumentInfo {
+	if len(infos) == 0 {
+		return nil
+	}
+	result := make([]ArgumentInfo, len(infos))
+	for i, info := range infos {
+		result[i] = ArgumentInfo{
+			Kind:     info.kind,
+			Name:     info.name,
+			Required: info.required,
+			Type:     info.typ,
+			Fields:   exportArguments(info.fields),
+		}
+	}
+	return result
+}
+
+func handlerName(handler interface{}) string {
+	fn := runtime.FuncForPC(reflect.ValueOf(handler).Pointer())
+	if fn == nil {
+		return ""
+	}
+	return fn.Name()
+}
+
+// WriteRoutes renders routes as a table
+func WriteRoutes(w io.Writer, routes []RouteInfo) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	if _, err := fmt.Fprintln(tw, "METHOD\tPATTERN\tSTATUS\tHANDLER\tARGUMENTS"); err != nil {
+		return err
+	}
+	for _, route := range routes {
+		args := make([]string, len(route.Arguments))
+		for i, a := range route.Arguments {
+			args[i] = a.String()
+		}
+		_, err := fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%s\n", route.Method, route.Pattern, route.ReturnStatus, route.HandlerName, strings.Join(args, ", "))
+		if err != nil {
+			return err
+		}
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,64 @@
+package smartapi_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/mmbednarek/smartapi"
+	"github.com/stretchr/testify/require"
+)
+
+func routesTestHandler(ctx context.Context, id int, session string) error {
+	return nil
+}
+
+func TestRoutes(t *testing.T) {
+	type headers struct {
+		Foo string `smartapi:"r_header=X-Foo"`
+		Bar string
+	}
+
+	r := smartapi.NewRouter()
+	r.Route("/v1", func(r smartapi.Router) {
+		r.Route("/user", func(r smartapi.Router) {
+			r.Delete("/{id}", routesTestHandler,
+				smartapi.AsInt(smartapi.URLParam("id")),
+				smartapi.Cookie("session"),
+				smartapi.ResponseStatus(http.StatusAccepted),
+			)
+		}, smartapi.Context())
+		r.With().Post("/headers", func(h *headers) {},
+			smartapi.RequestStruct(headers{}),
+		)
+	})
+
+	routes := r.Routes()
+	require.Len(t, routes, 2)
+
+	require.Equal(t, smartapi.RouteInfo{
+		Method:  smartapi.MethodDelete,
+		Pattern: "/v1/user/{id}",
+		Arguments: []smartapi.ArgumentInfo{
+			{Kind: "context", Type: reflect.TypeOf((*context.Context)(nil)).Elem()},
+			{Kind: "url_param", Name: "id", Required: true, Type: reflect.TypeOf(0)},
+			{Kind: "cookie", Name: "session", Type: reflect.TypeOf("")},
+		},
+		ReturnStatus: http.StatusAccepted,
+		HandlerName:  "github.com/mmbednarek/smartapi_test.routesTestHandler",
+	}, routes[0])
+
+	require.Equal(t, "/v1/headers", routes[1].Pattern)
+	require.Equal(t, http.StatusNoContent, routes[1].ReturnStatus)
+	require.Equal(t, []smartapi.ArgumentInfo{
+		{Kind: "r_header", Name: "X-Foo", Required: true, Type: reflect.TypeOf("")},
+	}, routes[1].Arguments[0].Fields)
+
+	var buff bytes.Buffer
+	require.NoError(t, smartapi.WriteRoutes(&buff, routes[:1]))
+	require.Equal(t, "METHOD  PATTERN        STATUS  HANDLER                                                ARGUMENTS\n"+
+		"DELETE  /v1/user/{id}  202     github.com/mmbednarek/smartapi_test.routesTestHandler  context context.Context, url_param=id int, cookie=session string\n",
+		buff.String())
+}
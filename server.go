@@ -3,14 +3,15 @@ package smartapi
 import (
 	"fmt"
 	"net/http"
-
-	"github.com/go-chi/chi"
 )
 
 type endpointData struct {
 	arguments    []Argument
 	returnStatus int
 	query        bool
+	multipart    bool
+	options      []endpointOption
+	state        *routerState
 }
 
 // Server handles http endpoints
@@ -30,10 +31,7 @@ func StartAPI(a API, address string) error {
 // NewServer constructs a server
 func NewServer(logger Logger) *Server {
 	return &Server{
-		router: router{
-			chiRouter: chi.NewRouter(),
-			logger:    logger,
-		},
+		router: newRouter(logger),
 	}
 }
 
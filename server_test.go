@@ -2237,12 +2237,30 @@ func TestHandlersErrors(t *testing.T) {
 		{
 			name: "Invalid return type",
 			api: func(api smartapi.Router) {
-				api.Get("/test", func() chan int {
+				api.Get("/test", func() chan<- int {
 					return nil
 				})
 			},
 			expect: errors.New("endpoint /test: unsupported return type"),
 		},
+		{
+			name: "Write response and return reader",
+			api: func(api smartapi.Router) {
+				api.Get("/test", func(w http.ResponseWriter) io.Reader {
+					return nil
+				}, smartapi.ResponseWriter())
+			},
+			expect: errors.New("endpoint /test: cannot write response and return response"),
+		},
+		{
+			name: "Write response and return stream with error",
+			api: func(api smartapi.Router) {
+				api.Get("/test", func(w http.ResponseWriter) (<-chan int, error) {
+					return nil, nil
+				}, smartapi.ResponseWriter())
+			},
+			expect: errors.New("endpoint /test: cannot write response and return response"),
+		},
 		{
 			name: "Invalid return type 2",
 			api: func(api smartapi.Router) {
@@ -2282,68 +2300,68 @@ func TestHandlersErrors(t *testing.T) {
 		{
 			name: "QueryParam wrong type",
 			api: func(api smartapi.Router) {
-				api.Get("/test", func(value int) error {
+				api.Get("/test", func(value map[string]string) error {
 					return nil
 				},
 					smartapi.QueryParam("name"),
 				)
 			},
-			expect: errors.New("endpoint /test: (argument 0) expected a string type"),
+			expect: errors.New("endpoint /test: (argument 0) cannot convert a string to map[string]string"),
 		},
 		{
 			name: "Required QueryParam wrong type",
 			api: func(api smartapi.Router) {
-				api.Get("/test", func(value int) error {
+				api.Get("/test", func(value map[string]string) error {
 					return nil
 				},
 					smartapi.RequiredQueryParam("name"),
 				)
 			},
-			expect: errors.New("endpoint /test: (argument 0) expected a string type"),
+			expect: errors.New("endpoint /test: (argument 0) cannot convert a string to map[string]string"),
 		},
 		{
 			name: "PostQueryParam wrong type",
 			api: func(api smartapi.Router) {
-				api.Get("/test", func(value int) error {
+				api.Get("/test", func(value map[string]string) error {
 					return nil
 				},
 					smartapi.PostQueryParam("name"),
 				)
 			},
-			expect: errors.New("endpoint /test: (argument 0) expected a string type"),
+			expect: errors.New("endpoint /test: (argument 0) cannot convert a string to map[string]string"),
 		},
 		{
 			name: "Required PostQueryParam wrong type",
 			api: func(api smartapi.Router) {
-				api.Get("/test", func(value int) error {
+				api.Get("/test", func(value map[string]string) error {
 					return nil
 				},
 					smartapi.RequiredPostQueryParam("name"),
 				)
 			},
-			expect: errors.New("endpoint /test: (argument 0) expected a string type"),
+			expect: errors.New("endpoint /test: (argument 0) cannot convert a string to map[string]string"),
 		},
 		{
 			name: "URLParam wrong type",
 			api: func(api smartapi.Router) {
-				api.Get("/test/{name}", func(value int) error {
+				api.Get("/test/{name}", func(value map[string]string) error {
 					return nil
 				},
 					smartapi.URLParam("name"),
 				)
 			},
-			expect: errors.New("endpoint /test/{name}: (argument 0) expected a string type"),
+			expect: errors.New("endpoint /test/{name}: (argument 0) cannot convert a string to map[string]string"),
 		},
 		{
 			name: "Header wrong type",
 			api: func(api smartapi.Router) {
-				api.Get("/test", func(value int) error {
+				api.Get("/test", func(value map[string]string) error {
 					return nil
 				},
 					smartapi.Header("name"),
 				)
 			},
-			expect: errors.New("endpoint /test: (argument 0) expected a string type"),
+			expect: errors.New("endpoint /test: (argument 0) cannot convert a string to map[string]string"),
 		},
 		{
 			name: "Tag Struct Error",
@@ -2385,35 +2403,35 @@ func TestHandlersErrors(t *testing.T) {
 		{
 			name: "Required header wrong type",
 			api: func(api smartapi.Router) {
-				api.Get("/test", func(value int) error {
+				api.Get("/test", func(value map[string]string) error {
 					return nil
 				},
 					smartapi.RequiredHeader("name"),
 				)
 			},
-			expect: errors.New("endpoint /test: (argument 0) expected a string type"),
+			expect: errors.New("endpoint /test: (argument 0) cannot convert a string to map[string]string"),
 		},
 		{
 			name: "Cookie wrong type",
 			api: func(api smartapi.Router) {
-				api.Get("/test", func(value int) error {
+				api.Get("/test", func(value map[string]string) error {
 					return nil
 				},
 					smartapi.Cookie("name"),
 				)
 			},
-			expect: errors.New("endpoint /test: (argument 0) expected a string type"),
+			expect: errors.New("endpoint /test: (argument 0) cannot convert a string to map[string]string"),
 		},
 		{
 			name: "Required Cookie wrong type",
 			api: func(api smartapi.Router) {
-				api.Get("/test", func(value int) error {
+				api.Get("/test", func(value map[string]string) error {
 					return nil
 				},
 					smartapi.RequiredCookie("name"),
 				)
 			},
-			expect: errors.New("endpoint /test: (argument 0) expected a string type"),
+			expect: errors.New("endpoint /test: (argument 0) cannot convert a string to map[string]string"),
 		},
 		{
 			name: "XML body wrong type",
@@ -2559,8 +2577,8 @@ func TestHandlersErrors(t *testing.T) {
 		{
 			name: "Too many return arguments",
 			api: func(api smartapi.Router) {
-				api.Get("/test", func() (string, string, error) {
-					return "", "", nil
+				api.Get("/test", func() (string, int, string, error) {
+					return "", 0, "", nil
 				})
 			},
 			expect: errors.New("endpoint /test: invalid number of return arguments"),
@@ -2661,7 +2679,7 @@ func TestHandlersErrors(t *testing.T) {
 			api: func(api smartapi.Router) {
 				type exampleStruct struct {
 					Inner struct {
-						Header int `smartapi:"header=something"`
+						Header map[string]int `smartapi:"header=something"`
 					} `smartapi:"request_struct"`
 				}
 				api.Post("/test", func(s *exampleStruct) {
@@ -2669,7 +2687,7 @@ func TestHandlersErrors(t *testing.T) {
 					smartapi.RequestStruct(exampleStruct{}),
 				)
 			},
-			expect: errors.New("endpoint /test: (argument 0) (struct field Inner) (struct field Header) expected a string type"),
+			expect: errors.New("endpoint /test: (argument 0) (struct field Inner) (struct field Header) cannot convert a string to map[string]int"),
 		},
 		{
 			name: "Tag Struct Multiple Readers",
@@ -2703,14 +2721,14 @@ func TestHandlersErrors(t *testing.T) {
 			name: "Router Pass Error",
 			api: func(api smartapi.Router) {
 				api.Route("/v1/user", func(r smartapi.Router) {
-					r.Get("/test", func(qp int) {
+					r.Get("/test", func(qp chan int) {
 						require.Equal(t, "test", qp)
 					},
 						smartapi.QueryParam("test"),
 					)
 				})
 			},
-			expect: errors.New("route /v1/user: endpoint /test: (argument 0) expected a string type"),
+			expect: errors.New("route /v1/user: endpoint /test: (argument 0) cannot convert a string to chan int"),
 		},
 		{
 			name: "Router Pass Error",
@@ -3078,6 +3096,16 @@ func TestMethods(t *testing.T) {
 	}
 }
 
+var errTestNotFound = errors.New("not found")
+
+type testConflictError struct {
+	id string
+}
+
+func (e *testConflictError) Error() string {
+	return e.id + " exists"
+}
+
 func TestError(t *testing.T) {
 	type test struct {
 		name         string
@@ -3184,6 +3212,103 @@ func TestError(t *testing.T) {
 			responseCode: http.StatusInternalServerError,
 			responseBody: []byte(`{"status":500,"reason":"unknown"}` + "\n"),
 		},
+		{
+			name: "MapError",
+			request: func() *http.Request {
+				request, err := http.NewRequest("GET", "/test", nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return request
+			},
+			api: func(api *smartapi.Server) {
+				api.MapError(errTestNotFound, http.StatusNotFound, "not found")
+				api.Get("/test", func() error {
+					return fmt.Errorf("get user: %w", errTestNotFound)
+				})
+			},
+			logger: func() smartapi.Logger {
+				m := mocks.NewMockLogger(ctrl)
+				m.EXPECT().LogApiError(gomock.Any(), smartapi.Error(http.StatusNotFound, "get user: not found", "not found")).Return().Times(1)
+				return m
+			}(),
+			responseCode: http.StatusNotFound,
+			responseBody: []byte(`{"status":404,"reason":"not found"}` + "\n"),
+		},
+		{
+			name: "MapErrorAs",
+			request: func() *http.Request {
+				request, err := http.NewRequest("GET", "/test", nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return request
+			},
+			api: func(api *smartapi.Server) {
+				api.MapError(errTestNotFound, http.StatusNotFound, "not found")
+				api.MapErrorAs(new(*testConflictError), http.StatusConflict, "conflict")
+				api.Get("/test", func() error {
+					return fmt.Errorf("store user: %w", &testConflictError{id: "john"})
+				})
+			},
+			logger: func() smartapi.Logger {
+				m := mocks.NewMockLogger(ctrl)
+				m.EXPECT().LogApiError(gomock.Any(), smartapi.Error(http.StatusConflict, "store user: john exists", "conflict")).Return().Times(1)
+				return m
+			}(),
+			responseCode: http.StatusConflict,
+			responseBody: []byte(`{"status":409,"reason":"conflict"}` + "\n"),
+		},
+		{
+			name: "MapErrorFunc",
+			request: func() *http.Request {
+				request, err := http.NewRequest("GET", "/test", nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return request
+			},
+			api: func(api *smartapi.Server) {
+				api.MapErrorFunc(func(err error) bool {
+					return strings.HasPrefix(err.Error(), "timeout")
+				}, http.StatusGatewayTimeout, "timeout")
+				api.Get("/test", func() error {
+					return errors.New("timeout exceeded")
+				})
+			},
+			logger: func() smartapi.Logger {
+				m := mocks.NewMockLogger(ctrl)
+				m.EXPECT().LogApiError(gomock.Any(), smartapi.Error(http.StatusGatewayTimeout, "timeout exceeded", "timeout")).Return().Times(1)
+				return m
+			}(),
+			responseCode: http.StatusGatewayTimeout,
+			responseBody: []byte(`{"status":504,"reason":"timeout"}` + "\n"),
+		},
+		{
+			name: "ApiErrorNotMapped",
+			request: func() *http.Request {
+				request, err := http.NewRequest("GET", "/test", nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return request
+			},
+			api: func(api *smartapi.Server) {
+				api.MapErrorFunc(func(err error) bool {
+					return true
+				}, http.StatusGatewayTimeout, "timeout")
+				api.Get("/test", func() error {
+					return smartapi.Error(http.StatusForbidden, "message", "reason")
+				})
+			},
+			logger: func() smartapi.Logger {
+				m := mocks.NewMockLogger(ctrl)
+				m.EXPECT().LogApiError(gomock.Any(), smartapi.Error(http.StatusForbidden, "message", "reason")).Return().Times(1)
+				return m
+			}(),
+			responseCode: http.StatusForbidden,
+			responseBody: []byte(`{"status":403,"reason":"reason"}` + "\n"),
+		},
 	}
 
 	for _, tt := range tests {
@@ -5,12 +5,20 @@ import (
 	"log"
 )
 
-// Logger logs the outcome of unsuccessful http requests
+// Logger logs the outcome of unsuccessful http requests.
+// Recovered panics are logged by LogPanic if the logger also implements PanicLogger.
+// LogPanic isn't a method of Logger, so existing implementations keep compiling,
+// but they get panics through LogError with the value and the stack trace in the error message.
 type Logger interface {
 	LogApiError(ctx context.Context, err ApiError)
 	LogError(ctx context.Context, err error)
 }
 
+// PanicLogger is optionally implemented by a Logger to log recovered panics with their stack traces
+type PanicLogger interface {
+	LogPanic(ctx context.Context, value interface{}, stack []byte)
+}
+
 // API interface represents an API
 type API interface {
 	Start(string) error
@@ -59,5 +67,9 @@ func (defaultLogger) LogError(ctx context.Context, err error) {
 	log.Print(err)
 }
 
+func (defaultLogger) LogPanic(ctx context.Context, value interface{}, stack []byte) {
+	log.Printf("panic: %v\n%s", value, stack)
+}
+
 // DefaultLogger is simple implementation of the Logger interface
 var DefaultLogger Logger = defaultLogger{}
@@ -0,0 +1,26 @@
+// Package smartapitest provides utilities for testing smartapi endpoints
+package smartapitest
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/mmbednarek/smartapi"
+)
+
+// DialWebSocket connects to a websocket endpoint of a handler served by a local test server.
+// The server is closed together with the connection. A Host header overrides the host of the request.
+// The response is returned along with an error if the handler doesn't upgrade the connection.
+func DialWebSocket(handler http.Handler, target string, header http.Header) (smartapi.Conn, *http.Response, error) {
+	server := httptest.NewServer(handler)
+	conn, response, err := smartapi.DialWebSocket(server.URL+target, header)
+	if err != nil {
+		server.Close()
+		return nil, response, err
+	}
+	go func() {
+		<-conn.Context().Done()
+		server.Close()
+	}()
+	return conn, response, nil
+}
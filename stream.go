@@ -0,0 +1,179 @@
+package smartapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+)
+
+const streamChunkSize = 32 << 10
+
+// StreamFormat selects how values received from a channel returned by a handler are written
+type StreamFormat int
+
+const (
+	// NDJSON writes every value as a json document in a separate line with application/x-ndjson content type. This is the default.
+	NDJSON StreamFormat = iota
+	// JSONArray writes values as elements of a json array with application/json content type
+	JSONArray
+)
+
+// Stream sets the format of values received from a channel returned by a handler
+func Stream(format StreamFormat) EndpointParam {
+	return configOption(func(c *endpointConfig) {
+		c.streamFormat = format
+	})
+}
+
+// isReaderType checks if a handler returns an interface of a reader
+func isReaderType(typ reflect.Type) bool {
+	return typ.Kind() == reflect.Interface && typ.Implements(readerType)
+}
+
+// isStreamType checks if a handler returns a channel values can be received from
+func isStreamType(typ reflect.Type) bool {
+	return typ.Kind() == reflect.Chan && typ.ChanDir()&reflect.RecvDir != 0 && isResponseKind(typ.Elem().Kind())
+}
+
+func flush(w http.ResponseWriter) {
+	if flusher, ok := w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func logStreamError(r *http.Request, logger Logger, err error) {
+	if logger != nil {
+		logger.LogError(r.Context(), fmt.Errorf("stream interrupted: %w", err))
+	}
+}
+
+// writeReader copies a reader into the response flushing every chunk. The reader is closed if it's an io.Closer.
+// Content-Type is detected from the first chunk unless it's already set. An empty reader results in no content.
+func writeReader(w http.ResponseWriter, r *http.Request, logger Logger, reader io.Reader) {
+	if closer, ok := reader.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	buffer := make([]byte, streamChunkSize)
+	written := false
+	for r.Context().Err() == nil {
+		n, err := reader.Read(buffer)
+		if n > 0 {
+			if !written {
+				if len(w.Header().Get("Content-Type")) == 0 {
+					w.Header().Set("Content-Type", http.DetectContentType(buffer[:n]))
+				}
+				w.WriteHeader(http.StatusOK)
+				written = true
+			}
+			if _, err := w.Write(buffer[:n]); err != nil {
+				logStreamError(r, logger, err)
+				return
+			}
+			flush(w)
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if !written {
+				handleError(w, r, logger, err)
+				return
+			}
+			logStreamError(r, logger, err)
+			return
+		}
+	}
+	if !written {
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// writeChannel writes values received from a channel until it's closed or the request's context is done
+func writeChannel(w http.ResponseWriter, r *http.Request, logger Logger, ch reflect.Value) {
+	format := configOf(r).streamFormat
+	if format == JSONArray {
+		w.Header().Set("Content-Type", "application/json")
+	} else {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+	}
+	w.WriteHeader(http.StatusOK)
+
+	if format == JSONArray {
+		if _, err := w.Write([]byte("[")); err != nil {
+			logStreamError(r, logger, err)
+			return
+		}
+	}
+	flush(w)
+
+	cases := []reflect.SelectCase{
+		{Dir: reflect.SelectRecv, Chan: ch},
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(r.Context().Done())},
+	}
+	for first := true; ; first = false {
+		chosen, value, ok := reflect.Select(cases)
+		if chosen == 1 {
+			return
+		}
+		if !ok {
+			break
+		}
+
+		data, err := json.Marshal(value.Interface())
+		if err != nil {
+			logStreamError(r, logger, err)
+			return
+		}
+		if format == JSONArray && !first {
+			data = append([]byte(","), data...)
+		}
+		if format == NDJSON {
+			data = append(data, '\n')
+		}
+		if _, err := w.Write(data); err != nil {
+			logStreamError(r, logger, err)
+			return
+		}
+		flush(w)
+	}
+
+	if format == JSONArray {
+		if _, err := w.Write([]byte("]\n")); err != nil {
+			logStreamError(r, logger, err)
+		}
+	}
+}
+
+type streamHandler struct {
+	handlerFunc interface{}
+	withError   bool
+}
+
+func (s streamHandler) handleRequest(w http.ResponseWriter, r *http.Request, logger Logger, endpoint endpointData) {
+	attribs, err := getCallAttributes(w, r, endpoint)
+	if err != nil {
+		handleError(w, r, logger, err)
+		return
+	}
+	value := reflect.ValueOf(s.handlerFunc)
+	result := value.Call(attribs)
+
+	if s.withError && !result[1].IsNil() {
+		handleErrorValue(w, r, logger, result[1])
+		return
+	}
+
+	responseValue := result[0]
+	if responseValue.IsNil() {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if responseValue.Kind() == reflect.Chan {
+		writeChannel(w, r, logger, responseValue)
+		return
+	}
+	writeReader(w, r, logger, responseValue.Interface().(io.Reader))
+}
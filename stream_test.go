@@ -0,0 +1,294 @@
+package smartapi_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/mmbednarek/smartapi"
+	"github.com/mmbednarek/smartapi/mocks"
+	"github.com/stretchr/testify/require"
+)
+
+type streamTestCloser struct {
+	io.Reader
+	closed bool
+}
+
+func (s *streamTestCloser) Close() error {
+	s.closed = true
+	return nil
+}
+
+type streamTestFailingReader struct {
+	data string
+	read bool
+}
+
+func (s *streamTestFailingReader) Read(p []byte) (int, error) {
+	if s.read {
+		return 0, errors.New("connection reset")
+	}
+	s.read = true
+	return copy(p, s.data), nil
+}
+
+type streamTestItem struct {
+	ID int `json:"id"`
+}
+
+func TestStream(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	closer := &streamTestCloser{Reader: strings.NewReader("Hello World")}
+
+	tests := []struct {
+		name         string
+		api          func(r smartapi.Router)
+		logger       smartapi.Logger
+		responseCode int
+		responseBody string
+		contentType  string
+	}{
+		{
+			name: "Reader",
+			api: func(r smartapi.Router) {
+				r.Get("/test", func() io.Reader {
+					return strings.NewReader("Hello World")
+				})
+			},
+			responseCode: http.StatusOK,
+			responseBody: "Hello World",
+			contentType:  "text/plain; charset=utf-8",
+		},
+		{
+			name: "Reader with content type",
+			api: func(r smartapi.Router) {
+				r.Get("/test", func(headers smartapi.Headers) (io.Reader, error) {
+					headers.Set("Content-Type", "text/csv")
+					return strings.NewReader("a,b\n1,2\n"), nil
+				}, smartapi.ResponseHeaders())
+			},
+			responseCode: http.StatusOK,
+			responseBody: "a,b\n1,2\n",
+			contentType:  "text/csv",
+		},
+		{
+			name: "ReadCloser is closed",
+			api: func(r smartapi.Router) {
+				r.Get("/test", func() (io.ReadCloser, error) {
+					return closer, nil
+				})
+			},
+			responseCode: http.StatusOK,
+			responseBody: "Hello World",
+			contentType:  "text/plain; charset=utf-8",
+		},
+		{
+			name: "Nil reader",
+			api: func(r smartapi.Router) {
+				r.Get("/test", func() (io.Reader, error) {
+					return nil, nil
+				})
+			},
+			responseCode: http.StatusNoContent,
+		},
+		{
+			name: "Empty reader",
+			api: func(r smartapi.Router) {
+				r.Get("/test", func() io.Reader {
+					return strings.NewReader("")
+				})
+			},
+			responseCode: http.StatusNoContent,
+		},
+		{
+			name: "Handler error",
+			api: func(r smartapi.Router) {
+				r.Get("/test", func() (io.Reader, error) {
+					return nil, smartapi.Error(http.StatusNotFound, "no file", "file not found")
+				})
+			},
+			responseCode: http.StatusNotFound,
+			responseBody: `{"status":404,"reason":"file not found"}` + "\n",
+			contentType:  "application/json",
+		},
+		{
+			name: "Reader error before the first byte",
+			api: func(r smartapi.Router) {
+				r.Get("/test", func() io.Reader {
+					return errorReader{}
+				})
+			},
+			responseCode: http.StatusInternalServerError,
+			responseBody: `{"status":500,"reason":"unknown"}` + "\n",
+			contentType:  "application/json",
+		},
+		{
+			name: "Reader error after the first byte",
+			api: func(r smartapi.Router) {
+				r.Get("/test", func() io.Reader {
+					return &streamTestFailingReader{data: "Hello"}
+				})
+			},
+			logger: func() smartapi.Logger {
+				m := mocks.NewMockLogger(ctrl)
+				m.EXPECT().LogError(gomock.Any(), gomock.Any()).Do(func(_ context.Context, err error) {
+					require.EqualError(t, err, "stream interrupted: connection reset")
+				}).Times(1)
+				return m
+			}(),
+			responseCode: http.StatusOK,
+			responseBody: "Hello",
+			contentType:  "text/plain; charset=utf-8",
+		},
+		{
+			name: "Channel",
+			api: func(r smartapi.Router) {
+				r.Get("/test", func() <-chan streamTestItem {
+					ch := make(chan streamTestItem)
+					go func() {
+						defer close(ch)
+						for i := 1; i <= 3; i++ {
+							ch <- streamTestItem{ID: i}
+						}
+					}()
+					return ch
+				})
+			},
+			responseCode: http.StatusOK,
+			responseBody: `{"id":1}` + "\n" + `{"id":2}` + "\n" + `{"id":3}` + "\n",
+			contentType:  "application/x-ndjson",
+		},
+		{
+			name: "Channel JSON array",
+			api: func(r smartapi.Router) {
+				r.Get("/test", func() (chan string, error) {
+					ch := make(chan string, 2)
+					ch <- "a"
+					ch <- "b"
+					close(ch)
+					return ch, nil
+				}, smartapi.Stream(smartapi.JSONArray))
+			},
+			responseCode: http.StatusOK,
+			responseBody: `["a","b"]` + "\n",
+			contentType:  "application/json",
+		},
+		{
+			name: "Empty channel JSON array",
+			api: func(r smartapi.Router) {
+				r.Get("/test", func() <-chan int {
+					ch := make(chan int)
+					close(ch)
+					return ch
+				}, smartapi.Stream(smartapi.JSONArray))
+			},
+			responseCode: http.StatusOK,
+			responseBody: "[]\n",
+			contentType:  "application/json",
+		},
+		{
+			name: "Channel is not buffered",
+			api: func(r smartapi.Router) {
+				r.Get("/test", func() <-chan int {
+					ch := make(chan int, 1)
+					ch <- 1
+					close(ch)
+					return ch
+				}, smartapi.BufferedResponse(), smartapi.Stream(smartapi.JSONArray))
+			},
+			responseCode: http.StatusOK,
+			responseBody: "[1]\n",
+			contentType:  "application/json",
+		},
+		{
+			name: "Nil channel",
+			api: func(r smartapi.Router) {
+				r.Get("/test", func() (<-chan int, error) {
+					return nil, nil
+				})
+			},
+			responseCode: http.StatusNoContent,
+		},
+		{
+			name: "Channel encoding error",
+			api: func(r smartapi.Router) {
+				r.Get("/test", func() <-chan interface{} {
+					ch := make(chan interface{}, 2)
+					ch <- 1
+					ch <- func() {}
+					close(ch)
+					return ch
+				})
+			},
+			logger: func() smartapi.Logger {
+				m := mocks.NewMockLogger(ctrl)
+				m.EXPECT().LogError(gomock.Any(), gomock.Any()).Times(1)
+				return m
+			}(),
+			responseCode: http.StatusOK,
+			responseBody: "1\n",
+			contentType:  "application/x-ndjson",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := smartapi.NewRouterLogger(tt.logger)
+			tt.api(r)
+
+			rr := httptest.NewRecorder()
+			req, err := http.NewRequest("GET", "/test", nil)
+			require.NoError(t, err)
+			r.MustHandler().ServeHTTP(rr, req)
+
+			require.Equal(t, tt.responseCode, rr.Code)
+			require.Equal(t, tt.responseBody, rr.Body.String())
+			require.Equal(t, tt.contentType, rr.Header().Get("Content-Type"))
+		})
+	}
+
+	require.True(t, closer.closed)
+}
+
+func TestStreamClientDisconnect(t *testing.T) {
+	done := make(chan struct{})
+	r := smartapi.NewRouterLogger(nil)
+	r.Get("/test", func(ctx context.Context) <-chan int {
+		ch := make(chan int)
+		go func() {
+			defer close(done)
+			for i := 0; ; i++ {
+				select {
+				case ch <- i:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+		return ch
+	}, smartapi.Context())
+
+	server := httptest.NewServer(r.MustHandler())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/test")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "application/x-ndjson", resp.Header.Get("Content-Type"))
+
+	line := make([]byte, 2)
+	_, err = io.ReadFull(resp.Body, line)
+	require.NoError(t, err)
+	require.Equal(t, "0\n", string(line))
+	require.NoError(t, resp.Body.Close())
+
+	<-done
+}
@@ -24,14 +24,31 @@ func parseArgument(tag string, fieldType reflect.Type) (Argument, error) {
 	return a, nil
 }
 
+// paramArgument creates an argument of a param with options parsed from tag's data
+func paramArgument(constructor func(name string, options ...ParamOption) EndpointParam, data string) (Argument, error) {
+	name, options, err := parseParamTag(data)
+	if err != nil {
+		return nil, err
+	}
+	return constructor(name, options...).(Argument), nil
+}
+
 func getArgument(kind string, data string, fieldType reflect.Type) (Argument, error) {
 	switch kind {
 	case "header":
-		return headerArgument{name: data}, nil
+		return paramArgument(Header, data)
 	case "r_header":
-		return requiredHeaderArgument{name: data}, nil
+		return paramArgument(RequiredHeader, data)
 	case "json_body":
+		if err := checkValidation(fieldType); err != nil {
+			return nil, err
+		}
 		return jsonBodyDirectArgument{typ: fieldType}, nil
+	case "body":
+		if err := checkValidation(fieldType); err != nil {
+			return nil, err
+		}
+		return bodyDirectArgument{typ: fieldType}, nil
 	case "string_body":
 		return stringBodyArgument{}, nil
 	case "byte_slice_body":
@@ -43,15 +60,17 @@ func getArgument(kind string, data string, fieldType reflect.Type) (Argument, er
 	case "context":
 		return contextArgument{}, nil
 	case "query_param":
-		return queryParamArgument{name: data}, nil
+		return paramArgument(QueryParam, data)
 	case "r_query_param":
-		return requiredQueryParamArgument{name: data}, nil
+		return paramArgument(RequiredQueryParam, data)
 	case "post_query_param":
-		return postQueryParamArgument{name: data}, nil
+		return paramArgument(PostQueryParam, data)
 	case "r_post_query_param":
-		return requiredPostQueryParamArgument{name: data}, nil
+		return paramArgument(RequiredPostQueryParam, data)
 	case "cookie":
-		return cookieArgument{name: data}, nil
+		return paramArgument(Cookie, data)
+	case "r_cookie":
+		return paramArgument(RequiredCookie, data)
 	case "response_headers":
 		return headerSetterArgument{}, nil
 	case "response_cookies":
@@ -60,6 +79,13 @@ func getArgument(kind string, data string, fieldType reflect.Type) (Argument, er
 		return responseWriterArgument{}, nil
 	case "request":
 		return fullRequestArgument{}, nil
+	case "form_file":
+		if fieldType == fileHeadersType {
+			return multipartFilesArgument{name: data}, nil
+		}
+		return multipartFileArgument{name: data}, nil
+	case "multipart_reader":
+		return multipartReaderArgument{}, nil
 	case "as_int":
 		arg, err := parseArgument(data, reflect.TypeOf(""))
 		if err != nil {
@@ -97,5 +123,5 @@ func getArgument(kind string, data string, fieldType reflect.Type) (Argument, er
 		}
 		return requestStruct(fieldType.Elem())
 	}
-	return nil, errors.New("unsupported tag")
+	return customTagArgument(kind, data, fieldType)
 }
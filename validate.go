@@ -0,0 +1,295 @@
+package smartapi
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+const validateTagName = "validate"
+
+// FieldError describes a field which didn't pass the validation
+type FieldError struct {
+	Field string      `json:"field" xml:"field" yaml:"field"`
+	Rule  string      `json:"rule" xml:"rule" yaml:"rule"`
+	Value interface{} `json:"value" xml:"value" yaml:"value"`
+}
+
+// ValidationError is returned when a decoded request doesn't satisfy rules of its validate tags
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (v ValidationError) Error() string {
+	fields := make([]string, len(v.Fields))
+	for i, f := range v.Fields {
+		fields[i] = fmt.Sprintf("%s (%s)", f.Field, f.Rule)
+	}
+	return "validation failed: " + strings.Join(fields, ", ")
+}
+
+// Status returns 422 UNPROCESSABLE ENTITY
+func (v ValidationError) Status() int {
+	return http.StatusUnprocessableEntity
+}
+
+// Reason returns the reason of the error
+func (v ValidationError) Reason() string {
+	return "validation failed"
+}
+
+type validationRule struct {
+	name  string
+	check func(v reflect.Value) bool
+}
+
+type fieldValidator struct {
+	index     int
+	name      string
+	omitEmpty bool
+	rules     []validationRule
+}
+
+type structValidator struct {
+	fields []fieldValidator
+}
+
+var validators sync.Map
+
+// checkValidation checks validate tags of a type, so the errors are reported when an endpoint is registered
+func checkValidation(t reflect.Type) error {
+	_, err := validatorOf(t)
+	return err
+}
+
+func validatorOf(t reflect.Type) (*structValidator, error) {
+	return compileValidator(t, map[reflect.Type]bool{})
+}
+
+func compileValidator(t reflect.Type, visiting map[reflect.Type]bool) (*structValidator, error) {
+	t = baseType(t)
+	if t.Kind() != reflect.Struct {
+		return nil, nil
+	}
+	if v, ok := validators.Load(t); ok {
+		return v.(*structValidator), nil
+	}
+	visiting[t] = true
+
+	v := &structValidator{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if len(f.PkgPath) != 0 {
+			continue
+		}
+		fv := fieldValidator{index: i, name: f.Name}
+		if name, ok := jsonFieldName(f); ok && len(name) != 0 {
+			fv.name = name
+		}
+
+		tag := f.Tag.Get(validateTagName)
+		if len(tag) != 0 {
+			for _, r := range strings.Split(tag, ",") {
+				if r == "omitempty" {
+					fv.omitEmpty = true
+					continue
+				}
+				rule, err := parseRule(r, f.Type)
+				if err != nil {
+					return nil, fmt.Errorf("(field %s) %w", f.Name, err)
+				}
+				fv.rules = append(fv.rules, rule)
+			}
+		}
+
+		nested := baseType(f.Type)
+		if nested.Kind() == reflect.Struct && nested != timeType {
+			if !visiting[nested] {
+				if _, err := compileValidator(nested, visiting); err != nil {
+					return nil, fmt.Errorf("(field %s) %w", f.Name, err)
+				}
+			}
+		} else if len(fv.rules) == 0 {
+			continue
+		}
+		v.fields = append(v.fields, fv)
+	}
+
+	validators.Store(t, v)
+	return v, nil
+}
+
+// baseType returns a type of elements of pointers and collections
+func baseType(t reflect.Type) reflect.Type {
+	for {
+		switch t.Kind() {
+		case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+			t = t.Elem()
+		default:
+			return t
+		}
+	}
+}
+
+func parseRule(rule string, t reflect.Type) (validationRule, error) {
+	name := rule
+	var param string
+	if eqAt := strings.Index(rule, "="); eqAt >= 0 {
+		name = rule[:eqAt]
+		param = rule[eqAt+1:]
+	}
+
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch name {
+	case "required":
+		return validationRule{name: name, check: func(v reflect.Value) bool {
+			return v.IsValid() && !v.IsZero()
+		}}, nil
+	case "min", "max":
+		limit, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			return validationRule{}, fmt.Errorf("invalid %s rule parameter %s", name, param)
+		}
+		measure, err := measureOf(t)
+		if err != nil {
+			return validationRule{}, fmt.Errorf("%s rule: %w", name, err)
+		}
+		if name == "min" {
+			return validationRule{name: rule, check: func(v reflect.Value) bool {
+				return measure(v) >= limit
+			}}, nil
+		}
+		return validationRule{name: rule, check: func(v reflect.Value) bool {
+			return measure(v) <= limit
+		}}, nil
+	case "email":
+		if t.Kind() != reflect.String {
+			return validationRule{}, errors.New("email rule requires a string")
+		}
+		return validationRule{name: name, check: func(v reflect.Value) bool {
+			addr, err := mail.ParseAddress(v.String())
+			return err == nil && addr.Address == v.String()
+		}}, nil
+	case "oneof":
+		allowed := strings.Fields(param)
+		if len(allowed) == 0 {
+			return validationRule{}, errors.New("oneof rule requires values")
+		}
+		return validationRule{name: rule, check: func(v reflect.Value) bool {
+			value := fmt.Sprint(v.Interface())
+			for _, a := range allowed {
+				if a == value {
+					return true
+				}
+			}
+			return false
+		}}, nil
+	}
+	return validationRule{}, fmt.Errorf("unsupported validation rule %s", name)
+}
+
+// measureOf returns a function measuring length of strings and collections or a value of numbers
+func measureOf(t reflect.Type) (func(v reflect.Value) float64, error) {
+	switch t.Kind() {
+	case reflect.String:
+		return func(v reflect.Value) float64 { return float64(utf8.RuneCountInString(v.String())) }, nil
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return func(v reflect.Value) float64 { return float64(v.Len()) }, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return func(v reflect.Value) float64 { return float64(v.Int()) }, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return func(v reflect.Value) float64 { return float64(v.Uint()) }, nil
+	case reflect.Float32, reflect.Float64:
+		return func(v reflect.Value) float64 { return v.Float() }, nil
+	}
+	return nil, fmt.Errorf("unsupported type %s", t)
+}
+
+// validate checks validate tags of a decoded value. Returns ValidationError on failure.
+func validate(v reflect.Value) error {
+	var fields []FieldError
+	validateValue(v, "", &fields)
+	if len(fields) > 0 {
+		return ValidationError{Fields: fields}
+	}
+	return nil
+}
+
+func validateValue(v reflect.Value, path string, result *[]FieldError) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		sv, err := validatorOf(v.Type())
+		if err != nil || sv == nil {
+			return
+		}
+		for _, f := range sv.fields {
+			validateField(v.Field(f.index), f, joinFieldPath(path, f.name), result)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			validateValue(v.Index(i), fmt.Sprintf("%s[%d]", path, i), result)
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			validateValue(iter.Value(), fmt.Sprintf("%s[%v]", path, iter.Key().Interface()), result)
+		}
+	}
+}
+
+func validateField(v reflect.Value, f fieldValidator, path string, result *[]FieldError) {
+	value := v
+	for value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			break
+		}
+		value = value.Elem()
+	}
+
+	if !(f.omitEmpty && value.IsZero()) {
+		isNil := value.Kind() == reflect.Ptr
+		for _, r := range f.rules {
+			if isNil && r.name != "required" {
+				continue
+			}
+			if !r.check(value) {
+				*result = append(*result, FieldError{Field: path, Rule: r.name, Value: fieldErrorValue(value)})
+				break
+			}
+		}
+	}
+
+	if baseType(v.Type()).Kind() == reflect.Struct {
+		validateValue(v, path, result)
+	}
+}
+
+func fieldErrorValue(v reflect.Value) interface{} {
+	if v.Kind() == reflect.Ptr && v.IsNil() {
+		return nil
+	}
+	return v.Interface()
+}
+
+func joinFieldPath(path, name string) string {
+	if len(path) == 0 {
+		return name
+	}
+	return path + "." + name
+}
@@ -0,0 +1,110 @@
+package smartapi_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mmbednarek/smartapi"
+	"github.com/stretchr/testify/require"
+)
+
+type validateTestAddress struct {
+	City string `json:"city" validate:"required"`
+}
+
+type validateTestUser struct {
+	Name      string                `json:"name" validate:"required,min=2,max=8"`
+	Email     string                `json:"email" validate:"omitempty,email"`
+	Role      string                `json:"role" validate:"oneof=admin user"`
+	Age       *int                  `json:"age" validate:"required,min=18"`
+	Addresses []validateTestAddress `json:"addresses" validate:"max=2"`
+}
+
+func TestValidation(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         string
+		responseCode int
+		responseBody string
+	}{
+		{
+			name:         "Valid",
+			body:         `{"name": "John", "role": "admin", "age": 21, "addresses": [{"city": "Warsaw"}]}`,
+			responseCode: http.StatusNoContent,
+		},
+		{
+			name:         "Invalid",
+			body:         `{"name": "J", "email": "john", "role": "root", "addresses": [{"city": ""}]}`,
+			responseCode: http.StatusUnprocessableEntity,
+			responseBody: `{"status":422,"reason":"validation failed","errors":[` +
+				`{"field":"name","rule":"min=2","value":"J"},` +
+				`{"field":"email","rule":"email","value":"john"},` +
+				`{"field":"role","rule":"oneof=admin user","value":"root"},` +
+				`{"field":"age","rule":"required","value":null},` +
+				`{"field":"addresses[0].city","rule":"required","value":""}]}` + "\n",
+		},
+		{
+			name:         "Too young",
+			body:         `{"name": "John", "role": "user", "age": 12}`,
+			responseCode: http.StatusUnprocessableEntity,
+			responseBody: `{"status":422,"reason":"validation failed","errors":[{"field":"age","rule":"min=18","value":12}]}` + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := smartapi.NewRouterLogger(nil)
+			r.Post("/test", func(u *validateTestUser) error {
+				return nil
+			},
+				smartapi.JSONBody(validateTestUser{}),
+			)
+
+			rr := httptest.NewRecorder()
+			request, err := http.NewRequest("POST", "/test", bytes.NewReader([]byte(tt.body)))
+			require.NoError(t, err)
+			r.MustHandler().ServeHTTP(rr, request)
+
+			require.Equal(t, tt.responseCode, rr.Code)
+			require.Equal(t, tt.responseBody, rr.Body.String())
+		})
+	}
+}
+
+func TestValidationRequestStruct(t *testing.T) {
+	type request struct {
+		Session string `smartapi:"header=X-Session" validate:"required"`
+	}
+
+	r := smartapi.NewRouterLogger(nil)
+	r.Get("/test", func(r *request) error {
+		return nil
+	},
+		smartapi.RequestStruct(request{}),
+	)
+
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", "/test", nil)
+	require.NoError(t, err)
+	r.MustHandler().ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	require.Equal(t, `{"status":422,"reason":"validation failed","errors":[{"field":"Session","rule":"required","value":""}]}`+"\n", rr.Body.String())
+}
+
+func TestValidationInvalidTags(t *testing.T) {
+	type invalid struct {
+		Name string `json:"name" validate:"min=abc"`
+	}
+
+	r := smartapi.NewRouter()
+	r.Post("/test", func(i *invalid) error {
+		return nil
+	},
+		smartapi.JSONBody(invalid{}),
+	)
+	_, err := r.Handler()
+	require.EqualError(t, err, "endpoint /test: (argument 0) (field Name) invalid min rule parameter abc")
+}
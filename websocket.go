@@ -0,0 +1,232 @@
+package smartapi
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+)
+
+const webSocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+var connType = reflect.TypeOf((*Conn)(nil)).Elem()
+
+// MaxMessageSize limits the size of a message received from a websocket. Larger messages close the connection with 1009 MESSAGE TOO BIG.
+func MaxMessageSize(bytes int64) EndpointParam {
+	return configOption(func(c *endpointConfig) {
+		c.maxMessageSize = bytes
+	})
+}
+
+// WebSocketOrigins allows websocket connections from other origins. By default the origin must match the host.
+// An origin equal to "*" allows all origins.
+func WebSocketOrigins(origins ...string) EndpointParam {
+	return configOption(func(c *endpointConfig) {
+		c.webSocketOrigins = append(c.webSocketOrigins, origins...)
+	})
+}
+
+// webSocketParam marks an endpoint registered with WebSocket
+type webSocketParam struct{}
+
+func (webSocketParam) options() endpointOptions {
+	return flagWebSocket
+}
+
+type webSocketConnKey struct{}
+
+// webSocketConnArgument passes the connection to a websocket handler as its last argument
+type webSocketConnArgument struct{}
+
+func (webSocketConnArgument) options() endpointOptions {
+	return flagArgument
+}
+
+func (webSocketConnArgument) checkArg(arg reflect.Type) error {
+	if arg != connType {
+		return errors.New("expected smartapi.Conn")
+	}
+	return nil
+}
+
+func (webSocketConnArgument) getValue(w http.ResponseWriter, r *http.Request) (reflect.Value, error) {
+	conn, _ := r.Context().Value(webSocketConnKey{}).(*webSocketConn)
+	return reflect.ValueOf(conn).Convert(connType), nil
+}
+
+// checkWebSocketHandler checks if a handler takes a connection as its last argument and returns nothing or an error
+func checkWebSocketHandler(handlerFunc interface{}, arguments []Argument, writesResponse bool) (endpointHandler, error) {
+	fnType, err := checkArguments(handlerFunc, arguments)
+	if err != nil {
+		return nil, err
+	}
+	if writesResponse {
+		return nil, errors.New("cannot write response in a websocket handler")
+	}
+	switch fnType.NumOut() {
+	case 0:
+		return webSocketHandler{handlerFunc: handlerFunc}, nil
+	case 1:
+		if fnType.Out(0).Implements(errType) {
+			return webSocketHandler{handlerFunc: handlerFunc, withError: true}, nil
+		}
+	}
+	return nil, errors.New("websocket handler can only return an error")
+}
+
+type webSocketHandler struct {
+	handlerFunc interface{}
+	withError   bool
+}
+
+// handleRequest resolves handler's arguments, upgrades the connection and calls the handler.
+// The connection is closed once the handler returns, with 1011 INTERNAL ERROR if it returns an error.
+func (h webSocketHandler) handleRequest(w http.ResponseWriter, r *http.Request, logger Logger, endpoint endpointData) {
+	config := configOf(r)
+	conn := newWebSocketConn(r.Context(), false)
+	defer conn.cancel()
+	if config.maxMessageSize > 0 {
+		conn.maxMessageSize = config.maxMessageSize
+	}
+	r = r.WithContext(context.WithValue(conn.ctx, webSocketConnKey{}, conn))
+
+	interval := config.heartbeat
+	if interval == 0 {
+		interval = defaultHeartbeat
+	}
+	if interval > 0 {
+		conn.readTimeout = 2 * interval
+	}
+
+	attribs, err := getCallAttributes(w, r, endpoint)
+	if err != nil {
+		handleError(w, r, logger, err)
+		return
+	}
+	if err := upgradeWebSocket(w, r, conn); err != nil {
+		handleError(w, r, logger, err)
+		return
+	}
+	if interval > 0 {
+		go conn.ping(interval)
+	}
+	defer func() {
+		if v := recover(); v != nil {
+			_ = conn.Close(CloseInternalError, "internal error")
+			panic(v)
+		}
+	}()
+
+	result := reflect.ValueOf(h.handlerFunc).Call(attribs)
+	if !h.withError || result[0].IsNil() {
+		_ = conn.Close(CloseNormal, "")
+		return
+	}
+
+	err, _ = result[0].Interface().(error)
+	reason := "internal error"
+	if apiErr, ok := asApiError(r, err); ok {
+		reason = apiErr.Reason()
+		if logger != nil {
+			logger.LogApiError(r.Context(), apiErr)
+		}
+	} else if logger != nil {
+		logger.LogError(r.Context(), err)
+	}
+	_ = conn.Close(CloseInternalError, reason)
+}
+
+// upgradeWebSocket performs the RFC 6455 opening handshake and starts reading frames of the connection
+func upgradeWebSocket(w http.ResponseWriter, r *http.Request, conn *webSocketConn) error {
+	if !headerHasToken(r.Header, "Connection", "upgrade") || !headerHasToken(r.Header, "Upgrade", "websocket") {
+		w.Header().Set("Upgrade", "websocket")
+		return Error(http.StatusUpgradeRequired, "missing websocket upgrade headers", "websocket upgrade required")
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		return Error(http.StatusUpgradeRequired, "unsupported websocket version", "unsupported websocket version")
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if decoded, err := base64.StdEncoding.DecodeString(key); err != nil || len(decoded) != 16 {
+		return Error(http.StatusBadRequest, "invalid Sec-WebSocket-Key", "invalid websocket key")
+	}
+	if !originAllowed(r, configOf(r).webSocketOrigins) {
+		return Error(http.StatusForbidden, fmt.Sprintf("origin %s is not allowed", r.Header.Get("Origin")), "origin not allowed")
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		return errors.New("websocket: response writer cannot be hijacked")
+	}
+	netConn, rw, err := hijacker.Hijack()
+	if err != nil {
+		return fmt.Errorf("websocket: cannot hijack connection: %w", err)
+	}
+	// older http servers and other hijackers may leave deadlines of the request on the connection,
+	// reads of the websocket are limited by the heartbeat instead
+	if err := netConn.SetDeadline(time.Time{}); err != nil {
+		netConn.Close()
+		return fmt.Errorf("websocket: cannot reset deadlines: %w", err)
+	}
+
+	var response strings.Builder
+	response.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n")
+	response.WriteString("Sec-WebSocket-Accept: " + webSocketAccept(key) + "\r\n")
+	for name, values := range w.Header() {
+		for _, value := range values {
+			response.WriteString(name + ": " + value + "\r\n")
+		}
+	}
+	response.WriteString("\r\n")
+	if _, err := rw.WriteString(response.String()); err != nil {
+		netConn.Close()
+		return fmt.Errorf("websocket: cannot write handshake: %w", err)
+	}
+	if err := rw.Flush(); err != nil {
+		netConn.Close()
+		return fmt.Errorf("websocket: cannot write handshake: %w", err)
+	}
+
+	conn.start(netConn, rw.Reader)
+	return nil
+}
+
+func webSocketAccept(key string) string {
+	hash := sha1.Sum([]byte(key + webSocketGUID))
+	return base64.StdEncoding.EncodeToString(hash[:])
+}
+
+// headerHasToken checks if a comma separated header contains a token
+func headerHasToken(header http.Header, name, token string) bool {
+	for _, value := range header[http.CanonicalHeaderKey(name)] {
+		for _, t := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func originAllowed(r *http.Request, allowed []string) bool {
+	origin := r.Header.Get("Origin")
+	if len(origin) == 0 {
+		return true
+	}
+	for _, a := range allowed {
+		if a == "*" || strings.EqualFold(a, origin) {
+			return true
+		}
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Host, r.Host)
+}
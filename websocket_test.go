@@ -0,0 +1,299 @@
+package smartapi_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mmbednarek/smartapi"
+	"github.com/mmbednarek/smartapi/smartapitest"
+	"github.com/stretchr/testify/require"
+)
+
+type webSocketTestMessage struct {
+	ID   string `json:"id,omitempty"`
+	Text string `json:"text"`
+}
+
+func webSocketEcho(id string, conn smartapi.Conn) error {
+	for {
+		var msg webSocketTestMessage
+		if err := conn.Receive(&msg); err != nil {
+			var closeErr *smartapi.CloseError
+			if errors.As(err, &closeErr) {
+				return nil
+			}
+			return err
+		}
+		msg.ID = id
+		if err := conn.Send(msg); err != nil {
+			return err
+		}
+	}
+}
+
+func TestWebSocket(t *testing.T) {
+	r := smartapi.NewRouterLogger(nil)
+	r.WebSocket("/echo/{id}", webSocketEcho, smartapi.URLParam("id"))
+
+	conn, resp, err := smartapitest.DialWebSocket(r.MustHandler(), "/echo/42", nil)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+
+	require.NoError(t, conn.Send(webSocketTestMessage{Text: "hello"}))
+	var msg webSocketTestMessage
+	require.NoError(t, conn.Receive(&msg))
+	require.Equal(t, webSocketTestMessage{ID: "42", Text: "hello"}, msg)
+
+	require.NoError(t, conn.Send(webSocketTestMessage{Text: strings.Repeat("a", 70000)}))
+	require.NoError(t, conn.Receive(&msg))
+	require.Len(t, msg.Text, 70000)
+
+	require.NoError(t, conn.Close(smartapi.CloseNormal, ""))
+	<-conn.Context().Done()
+	require.Equal(t, smartapi.ErrConnClosed, conn.Send(msg))
+
+	require.Equal(t, http.StatusSwitchingProtocols, r.Routes()[0].ReturnStatus)
+}
+
+func TestWebSocketClose(t *testing.T) {
+	tests := []struct {
+		name    string
+		api     func(r smartapi.Router)
+		send    interface{}
+		expect  *smartapi.CloseError
+		message string
+	}{
+		{
+			name: "Handler returns",
+			api: func(r smartapi.Router) {
+				r.WebSocket("/test", func(conn smartapi.Conn) {
+					require.NoError(t, conn.Send("bye"))
+				})
+			},
+			message: "bye",
+			expect:  &smartapi.CloseError{Code: smartapi.CloseNormal},
+		},
+		{
+			name: "Handler error",
+			api: func(r smartapi.Router) {
+				r.WebSocket("/test", func(conn smartapi.Conn) error {
+					return errors.New("database unavailable")
+				})
+			},
+			expect: &smartapi.CloseError{Code: smartapi.CloseInternalError, Reason: "internal error"},
+		},
+		{
+			name: "Handler api error",
+			api: func(r smartapi.Router) {
+				r.WebSocket("/test", func(conn smartapi.Conn) error {
+					return smartapi.Error(http.StatusForbidden, "no access", "forbidden")
+				})
+			},
+			expect: &smartapi.CloseError{Code: smartapi.CloseInternalError, Reason: "forbidden"},
+		},
+		{
+			name: "Message too big",
+			api: func(r smartapi.Router) {
+				r.WebSocket("/test", webSocketEcho, smartapi.Header("X-Id"), smartapi.MaxMessageSize(16))
+			},
+			send:   webSocketTestMessage{Text: "this message is too long"},
+			expect: &smartapi.CloseError{Code: smartapi.CloseMessageTooBig, Reason: "message too big"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := smartapi.NewRouterLogger(nil)
+			tt.api(r)
+
+			conn, _, err := smartapitest.DialWebSocket(r.MustHandler(), "/test", nil)
+			require.NoError(t, err)
+			defer conn.Close(smartapi.CloseNormal, "")
+
+			if len(tt.message) != 0 {
+				var msg interface{}
+				require.NoError(t, conn.Receive(&msg))
+				require.Equal(t, tt.message, msg)
+			}
+			if tt.send != nil {
+				require.NoError(t, conn.Send(tt.send))
+			}
+			var msg interface{}
+			require.Equal(t, tt.expect, conn.Receive(&msg))
+		})
+	}
+}
+
+func TestWebSocketHandshake(t *testing.T) {
+	tests := []struct {
+		name         string
+		api          func(r smartapi.Router)
+		header       http.Header
+		responseCode int
+		responseBody string
+	}{
+		{
+			name: "Missing argument",
+			api: func(r smartapi.Router) {
+				r.WebSocket("/test", func(token string, conn smartapi.Conn) {}, smartapi.RequiredHeader("Authorization"))
+			},
+			responseCode: http.StatusBadRequest,
+			responseBody: `{"status":400,"reason":"missing required header Authorization"}` + "\n",
+		},
+		{
+			name: "Origin not allowed",
+			api: func(r smartapi.Router) {
+				r.WebSocket("/test", func(conn smartapi.Conn) {})
+			},
+			header:       http.Header{"Origin": {"https://evil.example.com"}},
+			responseCode: http.StatusForbidden,
+			responseBody: `{"status":403,"reason":"origin not allowed"}` + "\n",
+		},
+		{
+			name: "Origin allowed",
+			api: func(r smartapi.Router) {
+				r.WebSocket("/test", func(conn smartapi.Conn) {}, smartapi.WebSocketOrigins("https://app.example.com"))
+			},
+			header:       http.Header{"Origin": {"https://app.example.com"}},
+			responseCode: http.StatusSwitchingProtocols,
+		},
+		{
+			name: "Same origin",
+			api: func(r smartapi.Router) {
+				r.WebSocket("/test", func(conn smartapi.Conn) {})
+			},
+			header:       http.Header{"Origin": {"http://example.com"}, "Host": {"example.com"}},
+			responseCode: http.StatusSwitchingProtocols,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := smartapi.NewRouterLogger(nil)
+			tt.api(r)
+
+			conn, resp, err := smartapitest.DialWebSocket(r.MustHandler(), "/test", tt.header)
+			require.Equal(t, tt.responseCode, resp.StatusCode)
+			if tt.responseCode == http.StatusSwitchingProtocols {
+				require.NoError(t, err)
+				require.NoError(t, conn.Close(smartapi.CloseNormal, ""))
+				return
+			}
+			require.Error(t, err)
+			body, err := ioutil.ReadAll(resp.Body)
+			require.NoError(t, err)
+			require.Equal(t, tt.responseBody, string(body))
+		})
+	}
+}
+
+func TestWebSocketContext(t *testing.T) {
+	done := make(chan struct{})
+	r := smartapi.NewRouterLogger(nil)
+	r.WebSocket("/test", func(ctx context.Context, conn smartapi.Conn) {
+		defer close(done)
+		require.NoError(t, conn.Send("ready"))
+		<-ctx.Done()
+	}, smartapi.Context())
+
+	conn, _, err := smartapitest.DialWebSocket(r.MustHandler(), "/test", nil)
+	require.NoError(t, err)
+	var msg string
+	require.NoError(t, conn.Receive(&msg))
+	require.Equal(t, "ready", msg)
+
+	require.NoError(t, conn.Close(smartapi.CloseGoingAway, ""))
+	<-done
+}
+
+func TestWebSocketPlainRequest(t *testing.T) {
+	r := smartapi.NewRouterLogger(nil)
+	r.WebSocket("/test", func(conn smartapi.Conn) {})
+
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", "/test", nil)
+	require.NoError(t, err)
+	r.MustHandler().ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusUpgradeRequired, rr.Code)
+	require.Equal(t, "websocket", rr.Header().Get("Upgrade"))
+	require.Equal(t, `{"status":426,"reason":"websocket upgrade required"}`+"\n", rr.Body.String())
+}
+
+func TestWebSocketErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		api    func(r smartapi.Router)
+		expect error
+	}{
+		{
+			name: "Missing connection",
+			api: func(r smartapi.Router) {
+				r.WebSocket("/test", func() {})
+			},
+			expect: errors.New("endpoint /test: number of arguments of a function doesn't match provided arguments"),
+		},
+		{
+			name: "Connection is not the last argument",
+			api: func(r smartapi.Router) {
+				r.WebSocket("/test", func(smartapi.Conn, string) {}, smartapi.Header("X-Id"))
+			},
+			expect: errors.New("endpoint /test: (argument 0) cannot convert a string to smartapi.Conn"),
+		},
+		{
+			name: "Invalid return type",
+			api: func(r smartapi.Router) {
+				r.WebSocket("/test", func(smartapi.Conn) string { return "" })
+			},
+			expect: errors.New("endpoint /test: websocket handler can only return an error"),
+		},
+		{
+			name: "Writes response",
+			api: func(r smartapi.Router) {
+				r.WebSocket("/test", func(http.ResponseWriter, smartapi.Conn) {}, smartapi.ResponseWriter())
+			},
+			expect: errors.New("endpoint /test: cannot write response in a websocket handler"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := smartapi.NewRouterLogger(nil)
+			tt.api(r)
+			_, err := r.Handler()
+			require.Equal(t, tt.expect, err)
+		})
+	}
+}
+
+func TestWebSocketServerReadTimeout(t *testing.T) {
+	r := smartapi.NewRouterLogger(nil)
+	r.WebSocket("/test", webSocketEcho, smartapi.Header("X-Id"), smartapi.Heartbeat(0))
+
+	server := httptest.NewUnstartedServer(r.MustHandler())
+	server.Config.ReadTimeout = 20 * time.Millisecond
+	server.Start()
+	defer server.Close()
+
+	conn, _, err := smartapi.DialWebSocket("ws"+strings.TrimPrefix(server.URL, "http")+"/test", nil)
+	require.NoError(t, err)
+	defer conn.Close(smartapi.CloseNormal, "")
+
+	// the idle connection outlives the read timeout of the http server
+	time.Sleep(100 * time.Millisecond)
+	require.NoError(t, conn.Send(webSocketTestMessage{Text: "hello"}))
+	var msg webSocketTestMessage
+	require.NoError(t, conn.Receive(&msg))
+	require.Equal(t, "hello", msg.Text)
+}
+
+func TestDialWebSocketScheme(t *testing.T) {
+	_, _, err := smartapi.DialWebSocket("ftp://localhost/test", nil)
+	require.EqualError(t, err, "websocket: unsupported scheme ftp")
+}
@@ -0,0 +1,97 @@
+package smartapi
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+)
+
+// DialWebSocket connects to a websocket endpoint. The url can have a ws, wss, http or https scheme.
+// A Host header overrides the host of the request. The response is returned along with an error if the server doesn't upgrade the connection.
+func DialWebSocket(target string, header http.Header) (Conn, *http.Response, error) {
+	u, err := url.Parse(target)
+	if err != nil {
+		return nil, nil, err
+	}
+	secure := false
+	switch u.Scheme {
+	case "ws", "http":
+		u.Scheme = "http"
+	case "wss", "https":
+		u.Scheme = "https"
+		secure = true
+	default:
+		return nil, nil, fmt.Errorf("websocket: unsupported scheme %s", u.Scheme)
+	}
+
+	key := make([]byte, 16)
+	if _, err := rand.Read(key); err != nil {
+		return nil, nil, err
+	}
+	request, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	for name, values := range header {
+		request.Header[name] = values
+	}
+	if host := request.Header.Get("Host"); len(host) != 0 {
+		request.Host = host
+	}
+	request.Header.Set("Connection", "Upgrade")
+	request.Header.Set("Upgrade", "websocket")
+	request.Header.Set("Sec-WebSocket-Version", "13")
+	request.Header.Set("Sec-WebSocket-Key", base64.StdEncoding.EncodeToString(key))
+
+	address := u.Host
+	if len(u.Port()) == 0 {
+		if secure {
+			address = net.JoinHostPort(u.Hostname(), "443")
+		} else {
+			address = net.JoinHostPort(u.Hostname(), "80")
+		}
+	}
+	var netConn net.Conn
+	if secure {
+		netConn, err = tls.Dial("tcp", address, &tls.Config{ServerName: u.Hostname()})
+	} else {
+		netConn, err = net.Dial("tcp", address)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	fail := func(response *http.Response, err error) (Conn, *http.Response, error) {
+		netConn.Close()
+		return nil, response, err
+	}
+	if err := request.Write(netConn); err != nil {
+		return fail(nil, err)
+	}
+	reader := bufio.NewReader(netConn)
+	response, err := http.ReadResponse(reader, request)
+	if err != nil {
+		return fail(nil, err)
+	}
+	if response.StatusCode != http.StatusSwitchingProtocols || response.Header.Get("Sec-WebSocket-Accept") != webSocketAccept(request.Header.Get("Sec-WebSocket-Key")) {
+		body, err := ioutil.ReadAll(response.Body)
+		if err != nil {
+			return fail(nil, err)
+		}
+		response.Body = ioutil.NopCloser(bytes.NewReader(body))
+		return fail(response, errors.New("websocket: bad handshake"))
+	}
+
+	conn := newWebSocketConn(context.Background(), true)
+	conn.start(netConn, reader)
+	return conn, response, nil
+}
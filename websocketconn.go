@@ -0,0 +1,455 @@
+package smartapi
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Close codes defined by RFC 6455
+const (
+	CloseNormal          = 1000
+	CloseGoingAway       = 1001
+	CloseProtocolError   = 1002
+	CloseUnsupportedData = 1003
+	CloseNoStatus        = 1005
+	CloseAbnormal        = 1006
+	CloseInvalidPayload  = 1007
+	ClosePolicyViolation = 1008
+	CloseMessageTooBig   = 1009
+	CloseInternalError   = 1011
+)
+
+const (
+	opContinuation = 0x0
+	opText         = 0x1
+	opBinary       = 0x2
+	opClose        = 0x8
+	opPing         = 0x9
+	opPong         = 0xA
+)
+
+// defaultMaxMessageSize is the default limit of a message received from a websocket
+const defaultMaxMessageSize = 1 << 20
+
+// closeTimeout is the time to wait for the peer to confirm closing the connection
+const closeTimeout = 5 * time.Second
+
+// maxCloseReason is the length of the longest close reason fitting a control frame with the close code
+const maxCloseReason = 123
+
+// defaultWriteTimeout limits the time of writing a frame, so a stalled peer can't block senders forever
+const defaultWriteTimeout = 10 * time.Second
+
+// ErrConnClosed is returned when a message is sent after the connection has been closed
+var ErrConnClosed = errors.New("websocket: connection closed")
+
+// Conn is a websocket connection exchanging json messages
+type Conn interface {
+	// Context is done once the connection is closed
+	Context() context.Context
+	// Receive waits for the next message and decodes it into v. A *CloseError is returned once the peer closes the connection.
+	Receive(v interface{}) error
+	// Send encodes v and sends it as a text message
+	Send(v interface{}) error
+	// Close sends a close frame and waits for the peer to confirm it.
+	// Codes reserved for local use, like 1005 and 1006, close the connection without a status code.
+	Close(code int, reason string) error
+}
+
+// CloseError is returned by Receive once the connection is closed
+type CloseError struct {
+	Code   int
+	Reason string
+}
+
+func (e *CloseError) Error() string {
+	if len(e.Reason) == 0 {
+		return fmt.Sprintf("websocket: closed with code %d", e.Code)
+	}
+	return fmt.Sprintf("websocket: closed with code %d: %s", e.Code, e.Reason)
+}
+
+type webSocketMessage struct {
+	opcode byte
+	data   []byte
+}
+
+// webSocketConn implements Conn over a hijacked connection. Frames are read by a single goroutine,
+// which answers pings and closes and passes data messages to Receive.
+type webSocketConn struct {
+	ctx            context.Context
+	cancel         context.CancelFunc
+	conn           net.Conn
+	reader         *bufio.Reader
+	client         bool
+	maxMessageSize int64
+	readTimeout    time.Duration
+	writeTimeout   time.Duration
+
+	messages chan webSocketMessage
+	done     chan struct{}
+	readErr  error
+
+	writeLock sync.Mutex
+	closeSent chan struct{}
+	closeOnce sync.Once
+	connOnce  sync.Once
+}
+
+func newWebSocketConn(ctx context.Context, client bool) *webSocketConn {
+	ctx, cancel := context.WithCancel(ctx)
+	return &webSocketConn{
+		ctx:            ctx,
+		cancel:         cancel,
+		client:         client,
+		maxMessageSize: defaultMaxMessageSize,
+		writeTimeout:   defaultWriteTimeout,
+		messages:       make(chan webSocketMessage),
+		done:           make(chan struct{}),
+		closeSent:      make(chan struct{}),
+	}
+}
+
+// start begins reading frames from an established connection
+func (c *webSocketConn) start(conn net.Conn, reader *bufio.Reader) {
+	c.conn = conn
+	c.reader = reader
+	go c.readLoop()
+}
+
+func (c *webSocketConn) Context() context.Context {
+	return c.ctx
+}
+
+func (c *webSocketConn) Receive(v interface{}) error {
+	message, ok := <-c.messages
+	if !ok {
+		return c.readErr
+	}
+	if err := json.Unmarshal(message.data, v); err != nil {
+		return fmt.Errorf("websocket: cannot decode message: %w", err)
+	}
+	return nil
+}
+
+func (c *webSocketConn) Send(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("websocket: cannot encode message: %w", err)
+	}
+	select {
+	case <-c.closeSent:
+		return ErrConnClosed
+	default:
+	}
+	return c.writeFrame(opText, data)
+}
+
+func (c *webSocketConn) Close(code int, reason string) error {
+	err := c.sendClose(code, reason)
+	if err == ErrConnClosed {
+		err = nil
+	}
+
+	timer := time.NewTimer(closeTimeout)
+	defer timer.Stop()
+	select {
+	case <-c.done:
+	case <-timer.C:
+	}
+	c.connOnce.Do(func() {
+		if closeErr := c.conn.Close(); err == nil {
+			err = closeErr
+		}
+	})
+	<-c.done
+	return err
+}
+
+// sendClose sends a close frame once, ErrConnClosed is returned if it has already been sent
+func (c *webSocketConn) sendClose(code int, reason string) error {
+	err := ErrConnClosed
+	c.closeOnce.Do(func() {
+		var payload []byte
+		if validCloseCode(code) {
+			reason = truncateCloseReason(reason)
+			payload = make([]byte, 2, 2+len(reason))
+			binary.BigEndian.PutUint16(payload, uint16(code))
+			payload = append(payload, reason...)
+		}
+		err = c.writeFrame(opClose, payload)
+		close(c.closeSent)
+	})
+	return err
+}
+
+// ping sends pings until the connection is closed
+func (c *webSocketConn) ping(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-c.closeSent:
+			return
+		case <-ticker.C:
+			if err := c.writeFrame(opPing, nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (c *webSocketConn) writeFrame(opcode byte, payload []byte) error {
+	header := make([]byte, 2, 14)
+	header[0] = 0x80 | opcode
+	switch length := len(payload); {
+	case length <= 125:
+		header[1] = byte(length)
+	case length <= 0xFFFF:
+		header[1] = 126
+		header = append(header, 0, 0)
+		binary.BigEndian.PutUint16(header[2:], uint16(length))
+	default:
+		header[1] = 127
+		header = append(header, 0, 0, 0, 0, 0, 0, 0, 0)
+		binary.BigEndian.PutUint64(header[2:], uint64(length))
+	}
+
+	data := payload
+	if c.client {
+		var key [4]byte
+		if _, err := rand.Read(key[:]); err != nil {
+			return err
+		}
+		header[1] |= 0x80
+		header = append(header, key[:]...)
+		data = make([]byte, len(payload))
+		copy(data, payload)
+		maskBytes(key, data)
+	}
+
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+	if err := c.conn.SetWriteDeadline(time.Now().Add(c.writeTimeout)); err != nil {
+		return err
+	}
+	if _, err := c.conn.Write(append(header, data...)); err != nil {
+		return err
+	}
+	return nil
+}
+
+func maskBytes(key [4]byte, data []byte) {
+	for i := range data {
+		data[i] ^= key[i%4]
+	}
+}
+
+type webSocketFrame struct {
+	fin     bool
+	opcode  byte
+	payload []byte
+}
+
+// readFrame reads a single frame, a *CloseError is returned if the frame violates the protocol
+func (c *webSocketConn) readFrame(limit int64) (webSocketFrame, error) {
+	var header [2]byte
+	if _, err := io.ReadFull(c.reader, header[:]); err != nil {
+		return webSocketFrame{}, err
+	}
+	frame := webSocketFrame{fin: header[0]&0x80 != 0, opcode: header[0] & 0x0F}
+	if header[0]&0x70 != 0 {
+		return frame, &CloseError{Code: CloseProtocolError, Reason: "reserved bits are set"}
+	}
+	masked := header[1]&0x80 != 0
+	if masked == c.client {
+		return frame, &CloseError{Code: CloseProtocolError, Reason: "invalid frame masking"}
+	}
+
+	length := int64(header[1] & 0x7F)
+	switch length {
+	case 126:
+		var extended [2]byte
+		if _, err := io.ReadFull(c.reader, extended[:]); err != nil {
+			return frame, err
+		}
+		length = int64(binary.BigEndian.Uint16(extended[:]))
+	case 127:
+		var extended [8]byte
+		if _, err := io.ReadFull(c.reader, extended[:]); err != nil {
+			return frame, err
+		}
+		length = int64(binary.BigEndian.Uint64(extended[:]))
+		if length < 0 {
+			return frame, &CloseError{Code: CloseProtocolError, Reason: "invalid frame length"}
+		}
+	}
+	if frame.opcode >= opClose && (!frame.fin || length > 125) {
+		return frame, &CloseError{Code: CloseProtocolError, Reason: "invalid control frame"}
+	}
+	if length > limit {
+		return frame, &CloseError{Code: CloseMessageTooBig, Reason: "message too big"}
+	}
+
+	var key [4]byte
+	if masked {
+		if _, err := io.ReadFull(c.reader, key[:]); err != nil {
+			return frame, err
+		}
+	}
+	frame.payload = make([]byte, length)
+	if _, err := io.ReadFull(c.reader, frame.payload); err != nil {
+		return frame, err
+	}
+	if masked {
+		maskBytes(key, frame.payload)
+	}
+	return frame, nil
+}
+
+// readLoop reads frames until the connection is closed. Data messages are passed to Receive.
+func (c *webSocketConn) readLoop() {
+	defer close(c.done)
+	defer c.cancel()
+	defer close(c.messages)
+
+	var message *webSocketMessage
+	for {
+		if c.readTimeout > 0 {
+			_ = c.conn.SetReadDeadline(time.Now().Add(c.readTimeout))
+		}
+		limit := c.maxMessageSize
+		if message != nil {
+			limit -= int64(len(message.data))
+		}
+		if limit < 125 {
+			limit = 125
+		}
+		frame, err := c.readFrame(limit)
+		if err != nil {
+			c.fail(err)
+			return
+		}
+
+		switch frame.opcode {
+		case opPing:
+			_ = c.writeFrame(opPong, frame.payload)
+			continue
+		case opPong:
+			continue
+		case opClose:
+			closeErr, err := closeFrameError(frame.payload)
+			if err != nil {
+				c.fail(err)
+				return
+			}
+			c.readErr = closeErr
+			code := closeErr.Code
+			if code == CloseNoStatus {
+				code = CloseNormal
+			}
+			_ = c.sendClose(code, "")
+			return
+		case opText, opBinary:
+			if message != nil {
+				c.fail(&CloseError{Code: CloseProtocolError, Reason: "expected a continuation frame"})
+				return
+			}
+			message = &webSocketMessage{opcode: frame.opcode}
+		case opContinuation:
+			if message == nil {
+				c.fail(&CloseError{Code: CloseProtocolError, Reason: "unexpected continuation frame"})
+				return
+			}
+		default:
+			c.fail(&CloseError{Code: CloseProtocolError, Reason: "unknown opcode"})
+			return
+		}
+
+		message.data = append(message.data, frame.payload...)
+		if int64(len(message.data)) > c.maxMessageSize {
+			c.fail(&CloseError{Code: CloseMessageTooBig, Reason: "message too big"})
+			return
+		}
+		if !frame.fin {
+			continue
+		}
+		if message.opcode == opText && !utf8.Valid(message.data) {
+			c.fail(&CloseError{Code: CloseInvalidPayload, Reason: "invalid utf-8 text"})
+			return
+		}
+
+		select {
+		case c.messages <- *message:
+		case <-c.closeSent:
+		case <-c.ctx.Done():
+			c.readErr = &CloseError{Code: CloseAbnormal}
+			return
+		}
+		message = nil
+	}
+}
+
+// fail stops reading after an error, protocol violations are reported to the peer
+func (c *webSocketConn) fail(err error) {
+	if closeErr, ok := err.(*CloseError); ok {
+		_ = c.sendClose(closeErr.Code, closeErr.Reason)
+		c.readErr = closeErr
+		return
+	}
+	c.readErr = &CloseError{Code: CloseAbnormal, Reason: err.Error()}
+}
+
+// closeFrameError decodes the payload of a received close frame, an error is returned if the payload is invalid
+func closeFrameError(payload []byte) (*CloseError, error) {
+	switch {
+	case len(payload) == 0:
+		return &CloseError{Code: CloseNoStatus}, nil
+	case len(payload) == 1:
+		return nil, &CloseError{Code: CloseProtocolError, Reason: "invalid close frame"}
+	}
+	code := int(binary.BigEndian.Uint16(payload))
+	if !validCloseCode(code) {
+		return nil, &CloseError{Code: CloseProtocolError, Reason: "invalid close code"}
+	}
+	if !utf8.Valid(payload[2:]) {
+		return nil, &CloseError{Code: CloseInvalidPayload, Reason: "invalid utf-8 close reason"}
+	}
+	return &CloseError{Code: code, Reason: string(payload[2:])}, nil
+}
+
+// validCloseCode checks if a close code can be sent in a close frame.
+// Codes 1005, 1006 and 1015 are reserved for reporting a missing status, an abnormal closure and a failed TLS handshake.
+func validCloseCode(code int) bool {
+	switch {
+	case code >= 3000 && code <= 4999:
+		return true
+	case code >= 1000 && code <= 1014:
+		return code != 1004 && code != CloseNoStatus && code != CloseAbnormal
+	}
+	return false
+}
+
+// truncateCloseReason cuts a close reason to fit a control frame without splitting a multi-byte rune
+func truncateCloseReason(reason string) string {
+	if len(reason) <= maxCloseReason {
+		return reason
+	}
+	cut := maxCloseReason
+	for cut > 0 && !utf8.RuneStart(reason[cut]) {
+		cut--
+	}
+	return reason[:cut]
+}
@@ -0,0 +1,247 @@
+package smartapi
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebSocketWriteTimeout(t *testing.T) {
+	local, remote := net.Pipe()
+	defer remote.Close()
+
+	conn := newWebSocketConn(context.Background(), false)
+	conn.conn = local
+	conn.writeTimeout = 10 * time.Millisecond
+
+	// the peer never reads, so the write can't complete
+	err := conn.Send("hello")
+	var netErr net.Error
+	require.True(t, errors.As(err, &netErr))
+	require.True(t, netErr.Timeout())
+}
+
+// webSocketTestPeer connects a started connection reading frames with a peer connection of the other side,
+// which isn't started, so a test can read and write raw frames of the peer. Setup functions configure the connection before it's started.
+func webSocketTestPeer(t *testing.T, client bool, setup ...func(c *webSocketConn)) (*webSocketConn, *webSocketConn) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		conn, _ := listener.Accept()
+		accepted <- conn
+	}()
+	dialed, err := net.Dial("tcp", listener.Addr().String())
+	require.NoError(t, err)
+	netConn := <-accepted
+	require.NotNil(t, netConn)
+
+	conn := newWebSocketConn(context.Background(), client)
+	for _, f := range setup {
+		f(conn)
+	}
+	conn.start(netConn, bufio.NewReader(netConn))
+	peer := newWebSocketConn(context.Background(), !client)
+	peer.conn = dialed
+	peer.reader = bufio.NewReader(dialed)
+	return conn, peer
+}
+
+func closePayload(code int, reason string) []byte {
+	payload := make([]byte, 2, 2+len(reason))
+	binary.BigEndian.PutUint16(payload, uint16(code))
+	return append(payload, reason...)
+}
+
+func TestWebSocketReceiveClose(t *testing.T) {
+	tests := []struct {
+		name     string
+		payload  []byte
+		expect   *CloseError
+		response []byte
+	}{
+		{
+			name:     "Close",
+			payload:  closePayload(CloseGoingAway, "bye"),
+			expect:   &CloseError{Code: CloseGoingAway, Reason: "bye"},
+			response: closePayload(CloseGoingAway, ""),
+		},
+		{
+			name:     "Application code",
+			payload:  closePayload(4000, ""),
+			expect:   &CloseError{Code: 4000},
+			response: closePayload(4000, ""),
+		},
+		{
+			name:     "No status",
+			expect:   &CloseError{Code: CloseNoStatus},
+			response: closePayload(CloseNormal, ""),
+		},
+		{
+			name:     "One byte payload",
+			payload:  []byte{0x03},
+			expect:   &CloseError{Code: CloseProtocolError, Reason: "invalid close frame"},
+			response: closePayload(CloseProtocolError, "invalid close frame"),
+		},
+		{
+			name:     "No status on the wire",
+			payload:  closePayload(CloseNoStatus, ""),
+			expect:   &CloseError{Code: CloseProtocolError, Reason: "invalid close code"},
+			response: closePayload(CloseProtocolError, "invalid close code"),
+		},
+		{
+			name:     "Abnormal closure on the wire",
+			payload:  closePayload(CloseAbnormal, ""),
+			expect:   &CloseError{Code: CloseProtocolError, Reason: "invalid close code"},
+			response: closePayload(CloseProtocolError, "invalid close code"),
+		},
+		{
+			name:     "TLS handshake code",
+			payload:  closePayload(1015, ""),
+			expect:   &CloseError{Code: CloseProtocolError, Reason: "invalid close code"},
+			response: closePayload(CloseProtocolError, "invalid close code"),
+		},
+		{
+			name:     "Code below 1000",
+			payload:  closePayload(999, ""),
+			expect:   &CloseError{Code: CloseProtocolError, Reason: "invalid close code"},
+			response: closePayload(CloseProtocolError, "invalid close code"),
+		},
+		{
+			name:     "Unassigned code",
+			payload:  closePayload(2000, ""),
+			expect:   &CloseError{Code: CloseProtocolError, Reason: "invalid close code"},
+			response: closePayload(CloseProtocolError, "invalid close code"),
+		},
+		{
+			name:     "Invalid utf-8 reason",
+			payload:  closePayload(CloseNormal, "\xff\xfe"),
+			expect:   &CloseError{Code: CloseInvalidPayload, Reason: "invalid utf-8 close reason"},
+			response: closePayload(CloseInvalidPayload, "invalid utf-8 close reason"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, peer := webSocketTestPeer(t, false)
+			defer server.conn.Close()
+			defer peer.conn.Close()
+
+			require.NoError(t, peer.writeFrame(opClose, tt.payload))
+			frame, err := peer.readFrame(125)
+			require.NoError(t, err)
+			require.Equal(t, byte(opClose), frame.opcode)
+			require.Equal(t, tt.response, frame.payload)
+
+			var msg interface{}
+			require.Equal(t, tt.expect, server.Receive(&msg))
+		})
+	}
+}
+
+func TestWebSocketSendClose(t *testing.T) {
+	tests := []struct {
+		name     string
+		code     int
+		reason   string
+		response []byte
+	}{
+		{
+			name:     "Close",
+			code:     CloseNormal,
+			reason:   "bye",
+			response: closePayload(CloseNormal, "bye"),
+		},
+		{
+			name:     "Long reason",
+			code:     CloseGoingAway,
+			reason:   strings.Repeat("a", 200),
+			response: closePayload(CloseGoingAway, strings.Repeat("a", 123)),
+		},
+		{
+			name:     "Multi-byte runes",
+			code:     CloseGoingAway,
+			reason:   strings.Repeat("é", 100),
+			response: closePayload(CloseGoingAway, strings.Repeat("é", 61)),
+		},
+		{
+			name:     "Reserved code",
+			code:     CloseAbnormal,
+			reason:   "lost",
+			response: []byte{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, peer := webSocketTestPeer(t, false)
+			defer server.conn.Close()
+			defer peer.conn.Close()
+
+			closed := make(chan error, 1)
+			go func() {
+				closed <- server.Close(tt.code, tt.reason)
+			}()
+
+			frame, err := peer.readFrame(125)
+			require.NoError(t, err)
+			require.Equal(t, byte(opClose), frame.opcode)
+			require.Equal(t, tt.response, frame.payload)
+
+			require.NoError(t, peer.writeFrame(opClose, frame.payload))
+			require.NoError(t, <-closed)
+		})
+	}
+}
+
+func TestWebSocketPing(t *testing.T) {
+	server, peer := webSocketTestPeer(t, false)
+	defer server.conn.Close()
+	defer peer.conn.Close()
+	go server.ping(time.Millisecond)
+
+	for i := 0; i < 3; i++ {
+		frame, err := peer.readFrame(125)
+		require.NoError(t, err)
+		require.Equal(t, byte(opPing), frame.opcode)
+		require.NoError(t, peer.writeFrame(opPong, frame.payload))
+	}
+}
+
+func TestWebSocketAnswersPing(t *testing.T) {
+	client, peer := webSocketTestPeer(t, true)
+	defer client.conn.Close()
+	defer peer.conn.Close()
+
+	for _, payload := range []string{"1", "2", "3"} {
+		require.NoError(t, peer.writeFrame(opPing, []byte(payload)))
+		frame, err := peer.readFrame(125)
+		require.NoError(t, err)
+		require.Equal(t, byte(opPong), frame.opcode)
+		require.Equal(t, payload, string(frame.payload))
+	}
+}
+
+func TestWebSocketReadTimeout(t *testing.T) {
+	server, peer := webSocketTestPeer(t, false, func(c *webSocketConn) {
+		c.readTimeout = 10 * time.Millisecond
+	})
+	defer server.conn.Close()
+	defer peer.conn.Close()
+
+	// the peer doesn't answer, so the server stops reading once the timeout passes
+	var msg interface{}
+	err := server.Receive(&msg)
+	var closeErr *CloseError
+	require.True(t, errors.As(err, &closeErr))
+	require.Equal(t, CloseAbnormal, closeErr.Code)
+}